@@ -0,0 +1,110 @@
+// Package lemin exposes the lem-in solver as a library, so that it can be
+// embedded in other programs without going through the command line.
+package lemin
+
+import (
+	"errors"
+	"io"
+	"lem-in/src"
+)
+
+// ErrNoPathSet is returned in exhaustive mode when no combination of paths
+// serves every start room at once, because the start rooms can only reach
+// the end rooms through shared rooms or tunnels. The default selector handles
+// such colonies by letting the start rooms take turns.
+var ErrNoPathSet = errors.New("exhaustive search found no path set serving every start room")
+
+// Options controls how Solve computes a solution.
+type Options struct {
+	// Exhaustive selects paths by enumerating every simple path
+	// (FindAllPathsBFS + FilterPath) instead of using max-flow. It is only
+	// practical on small maps, and fails with ErrNoPathSet when the start
+	// rooms have to share rooms or tunnels.
+	Exhaustive bool
+
+	// Capacity selects which of rooms and tunnels hold a single ant at a
+	// time. The zero value is the standard lem-in rule (both).
+	Capacity src.CapacityModel
+}
+
+// Solution is the result of solving a colony.
+type Solution struct {
+	Data       *src.LemInData // Parsed colony
+	Graph      *src.Graph     // Integer-indexed graph of the colony
+	Paths      [][]string     // Paths chosen for the ants, from start to end room
+	Assignment [][]int        // Ants sent along each path, indexed like Paths
+	Turns      []src.Turn     // Moves made during each turn
+	Bound      src.Bound      // Lower bound on the number of turns, to measure the gap to optimal
+}
+
+// Solve parses a colony description from r and computes how to move every
+// ant from the start room to the end room.
+func Solve(r io.Reader, opts Options) (*Solution, error) {
+	data, err := src.ParseInput(r)
+	if err != nil {
+		return nil, err
+	}
+	return SolveData(data, opts)
+}
+
+// SolveData computes how to move every ant of an already parsed colony. It
+// returns a *src.NoPathError, matching src.ErrNoPath, if the end room cannot
+// be reached from the start room.
+func SolveData(data *src.LemInData, opts Options) (*Solution, error) {
+	if err := data.CheckConnectivity(); err != nil {
+		return nil, err
+	}
+
+	solution, err := SolveGraph(src.NewGraph(data), data.NumAnts, opts)
+	if err != nil {
+		return nil, err
+	}
+	solution.Data = data
+	return solution, nil
+}
+
+// SolveStream parses a colony description from r with src.ParseStream, which
+// keeps neither the input lines nor a src.LemInData, and computes how to move
+// every ant. It is meant for very large colonies; the Solution has no Data,
+// and an unreachable end room is reported as src.ErrNoPath without the
+// components.
+func SolveStream(r io.Reader, opts Options) (*Solution, error) {
+	colony, _, err := src.ParseStream(r)
+	if err != nil {
+		return nil, err
+	}
+	return SolveGraph(colony.Graph(), colony.NumAnts, opts)
+}
+
+// SolveGraph computes how to move numAnts ants through the graph of a
+// colony. The Solution has no Data.
+func SolveGraph(g *src.Graph, numAnts int, opts Options) (*Solution, error) {
+	g.Capacity = opts.Capacity
+	var paths [][]int
+	var turns int
+	if opts.Exhaustive {
+		paths, turns = g.FilterPaths(g.AllPaths(), numAnts)
+		if turns == 0 {
+			return nil, ErrNoPathSet
+		}
+	} else {
+		paths, turns = g.OptimalPaths(numAnts)
+	}
+	if turns == 0 {
+		return nil, src.ErrNoPath
+	}
+
+	assignment, _ := g.Distribute(paths, numAnts)
+	return &Solution{
+		Graph:      g,
+		Paths:      g.PathsNames(paths),
+		Assignment: assignment,
+		Turns:      g.Simulate(paths, assignment),
+		Bound:      g.LowerBound(numAnts),
+	}, nil
+}
+
+// WriteMoves writes the solution's moves to w, one line of "Lx-room" moves per turn.
+func (s *Solution) WriteMoves(w io.Writer) error {
+	return src.WriteTurns(w, s.Turns)
+}
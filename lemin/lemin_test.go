@@ -0,0 +1,404 @@
+package lemin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"lem-in/src"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// solveAndVerify solves the colony described by input, then replays the
+// moves with src.VerifyCapacity and checks them against the lower bound.
+func solveAndVerify(t *testing.T, input string, opts Options) *Solution {
+	t.Helper()
+	solution, err := Solve(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatalf("Solve: %v", err)
+	}
+	var moves bytes.Buffer
+	if err := solution.WriteMoves(&moves); err != nil {
+		t.Fatal(err)
+	}
+	turns, err := src.VerifyCapacity(solution.Data, &moves, opts.Capacity)
+	if err != nil {
+		t.Fatalf("Verify: %v\n%s", err, moves.String())
+	}
+	if turns != len(solution.Turns) {
+		t.Errorf("Verify counted %d turns; the solution has %d", turns, len(solution.Turns))
+	}
+	if turns < solution.Bound.Turns {
+		t.Errorf("%d turns, below the lower bound of %d", turns, solution.Bound.Turns)
+	}
+	return solution
+}
+
+// TestSolveWeightedSharedRoom checks that an ant never waits in a long
+// tunnel for a full room, which the transcript cannot show.
+func TestSolveWeightedSharedRoom(t *testing.T) {
+	const input = `4
+##start
+r0 0 0
+##start
+r1 0 4
+##end
+r2 6 2
+r7 1 2
+r6 2 2
+r4 3 2
+r5 4 2
+r0-r7
+r7-r1
+r7-r6 4
+r6-r1 3
+r6-r4 3
+r4-r5
+r5-r2
+`
+	for _, capacity := range []src.CapacityModel{src.VertexAndEdgeCapacity, src.VertexCapacity, src.EdgeCapacity} {
+		t.Run(capacity.String(), func(t *testing.T) {
+			solveAndVerify(t, input, Options{Capacity: capacity})
+		})
+	}
+}
+
+// TestSolveStream checks that solving from the streaming parser gives a valid
+// schedule as short as the one solved from the parsed input lines.
+func TestSolveStream(t *testing.T) {
+	const input = `6
+##start
+s 0 0
+a 1 0
+b 1 1
+c 2 0
+##end
+e 3 0
+s-a
+s-b
+a-c 2
+b-e 3
+c-e
+`
+	solution := solveAndVerify(t, input, Options{})
+	streamed, err := SolveStream(strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("SolveStream: %v", err)
+	}
+	var moves bytes.Buffer
+	if err := streamed.WriteMoves(&moves); err != nil {
+		t.Fatal(err)
+	}
+	turns, err := src.Verify(solution.Data, &moves)
+	if err != nil {
+		t.Fatalf("Verify: %v\n%s", err, moves.String())
+	}
+	if turns != len(solution.Turns) {
+		t.Errorf("SolveStream took %d turns; Solve took %d", turns, len(solution.Turns))
+	}
+}
+
+// solveFile solves one of the example maps with solveAndVerify.
+func solveFile(t *testing.T, name string, opts Options) *Solution {
+	t.Helper()
+	input, err := os.ReadFile(filepath.Join("..", "examples", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return solveAndVerify(t, string(input), opts)
+}
+
+// TestSolveMaxFlowMatchesExhaustive checks that the max-flow selector finds
+// schedules as short as enumerating every path does, on the example maps
+// small enough to enumerate.
+func TestSolveMaxFlowMatchesExhaustive(t *testing.T) {
+	for _, name := range []string{"example.txt", "example00.txt", "example01.txt", "example02.txt", "example03.txt", "example04.txt", "example05.txt"} {
+		t.Run(name, func(t *testing.T) {
+			maxFlow := solveFile(t, name, Options{})
+			exhaustive := solveFile(t, name, Options{Exhaustive: true})
+			if len(maxFlow.Turns) > len(exhaustive.Turns) {
+				t.Errorf("max-flow took %d turns; exhaustive search took %d", len(maxFlow.Turns), len(exhaustive.Turns))
+			}
+		})
+	}
+}
+
+// blockingColony has a shortest path s-a-b-e of 3 turns that blocks the only
+// two disjoint paths, s-a-d-y-e and s-c-x-b-e of 4 turns.
+const blockingColony = `##start
+s 0 0
+a 1 0
+b 2 0
+c 1 1
+x 2 1
+d 2 2
+y 3 2
+##end
+e 3 0
+s-a
+a-b
+b-e
+s-c
+c-x
+x-b
+a-d
+d-y
+y-e
+`
+
+// TestSolveReroutesShortestPath checks that max-flow gives up the shortest
+// path when the two paths it blocks move the ants faster.
+func TestSolveReroutesShortestPath(t *testing.T) {
+	solution := solveAndVerify(t, "10\n"+blockingColony, Options{})
+	if len(solution.Paths) != 2 || len(solution.Turns) != 8 {
+		t.Errorf("%d paths in %d turns; want 2 paths in 8 turns: %v", len(solution.Paths), len(solution.Turns), solution.Paths)
+	}
+}
+
+// TestSolveChoosesPathsByTurns checks that the path set is chosen by the
+// number of turns it takes rather than by its number of paths, and that the
+// turns the selector predicts are the turns the simulation takes.
+func TestSolveChoosesPathsByTurns(t *testing.T) {
+	tests := []struct {
+		ants, paths, turns int
+	}{
+		{1, 1, 3},
+		{4, 2, 5},
+		{10, 2, 8},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d ants", test.ants), func(t *testing.T) {
+			solution := solveAndVerify(t, fmt.Sprintf("%d\n%s", test.ants, blockingColony), Options{})
+			if len(solution.Paths) != test.paths || len(solution.Turns) != test.turns {
+				t.Errorf("%d paths in %d turns; want %d paths in %d turns", len(solution.Paths), len(solution.Turns), test.paths, test.turns)
+			}
+			if _, turns := solution.Graph.OptimalPaths(test.ants); turns != len(solution.Turns) {
+				t.Errorf("OptimalPaths predicted %d turns; the simulation took %d", turns, len(solution.Turns))
+			}
+		})
+	}
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		turns int
+	}{
+		{"example.txt", 5},
+		{"example00.txt", 6},
+		{"example01.txt", 8},
+		{"example02.txt", 11},
+		{"example03.txt", 6},
+		{"example04.txt", 6},
+		{"example05.txt", 8},
+		{"example06.txt", 31},
+		{"example07.txt", 256},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			solution := solveFile(t, test.name, Options{})
+			if len(solution.Turns) != test.turns {
+				t.Errorf("%d turns; want %d", len(solution.Turns), test.turns)
+			}
+			if len(solution.Assignment) != len(solution.Paths) {
+				t.Fatalf("%d ant lists for %d paths", len(solution.Assignment), len(solution.Paths))
+			}
+			ants := 0
+			for _, assigned := range solution.Assignment {
+				ants += len(assigned)
+			}
+			if ants != solution.Data.NumAnts {
+				t.Errorf("%d ants assigned to paths; want %d", ants, solution.Data.NumAnts)
+			}
+		})
+	}
+}
+
+func TestSolveInvalidInput(t *testing.T) {
+	for _, name := range []string{"badexample00.txt", "badexample01.txt"} {
+		t.Run(name, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("..", "examples", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			if solution, err := Solve(file, Options{}); err == nil {
+				t.Errorf("Solve = %d turns; want an error", len(solution.Turns))
+			}
+		})
+	}
+}
+
+// TestSolveCapacityModels checks that path selection and simulation follow
+// the capacity model: the paths through the hall share a room but no tunnel.
+func TestSolveCapacityModels(t *testing.T) {
+	const input = `10
+##start
+s 0 0
+a 1 0
+b 1 1
+hall 2 0
+d 3 0
+f 3 1
+##end
+e 4 0
+s-a
+s-b
+a-hall
+b-hall
+hall-d
+hall-f
+d-e
+f-e
+`
+	tests := []struct {
+		capacity     src.CapacityModel
+		paths, turns int
+	}{
+		{src.VertexAndEdgeCapacity, 1, 13},
+		{src.VertexCapacity, 1, 13},
+		{src.EdgeCapacity, 2, 8},
+	}
+	for _, test := range tests {
+		t.Run(test.capacity.String(), func(t *testing.T) {
+			solution := solveAndVerify(t, input, Options{Capacity: test.capacity})
+			if len(solution.Paths) != test.paths || len(solution.Turns) != test.turns {
+				t.Errorf("%d paths in %d turns; want %d paths in %d turns", len(solution.Paths), len(solution.Turns), test.paths, test.turns)
+			}
+		})
+	}
+
+	for _, name := range []string{"example01.txt", "example02.txt", "example05.txt", "example06.txt"} {
+		for _, capacity := range []src.CapacityModel{src.VertexCapacity, src.EdgeCapacity} {
+			t.Run(name+"/"+capacity.String(), func(t *testing.T) {
+				solveFile(t, name, Options{Capacity: capacity})
+			})
+		}
+	}
+}
+
+// TestSolveRoomCapacity checks that a room holding two ants carries two
+// paths, in path selection and in the simulation.
+func TestSolveRoomCapacity(t *testing.T) {
+	const input = `10
+##start
+s 0 0
+a 1 0
+b 1 1
+##capacity 2
+hall 2 0
+d 3 0
+f 3 1
+##end
+e 4 0
+s-a
+s-b
+a-hall
+b-hall
+hall-d
+hall-f
+d-e
+f-e
+`
+	solution := solveAndVerify(t, input, Options{})
+	if len(solution.Paths) != 2 || len(solution.Turns) != 8 {
+		t.Errorf("%d paths in %d turns; want 2 paths in 8 turns", len(solution.Paths), len(solution.Turns))
+	}
+}
+
+// TestSolveSeveralStarts checks that the ants of each start room leave from
+// it and are spread over the paths leaving from it.
+func TestSolveSeveralStarts(t *testing.T) {
+	const input = `10
+##start
+##ants 7
+nest1 0 0
+##start
+nest2 0 4
+a 1 0
+b 1 1
+c 1 4
+##end
+food1 2 0
+##end
+food2 2 4
+nest1-a
+nest1-b
+nest2-c
+a-food1
+b-food1
+c-food2
+`
+	solution := solveAndVerify(t, input, Options{})
+	for i, path := range solution.Paths {
+		for _, ant := range solution.Assignment[i] {
+			want := "nest1"
+			if ant > 7 {
+				want = "nest2"
+			}
+			if path[0] != want {
+				t.Errorf("ant %d leaves from %s; want %s", ant, path[0], want)
+			}
+		}
+	}
+	// nest1 sends 7 ants over two paths of 2 moves, nest2 3 ants over one
+	if len(solution.Turns) != 5 {
+		t.Errorf("%d turns; want 5", len(solution.Turns))
+	}
+}
+
+// TestSolveSharedCorridor checks that start rooms which can only reach the
+// end through the same room take turns using it, and that exhaustive mode,
+// which cannot do that, fails with ErrNoPathSet.
+func TestSolveSharedCorridor(t *testing.T) {
+	const input = `4
+##start
+s1 0 0
+##start
+s2 0 2
+m 1 1
+##end
+e 2 1
+s1-m
+s2-m
+m-e
+`
+	solution := solveAndVerify(t, input, Options{})
+	if len(solution.Turns) != 5 {
+		t.Errorf("%d turns; want 5", len(solution.Turns))
+	}
+	if _, err := Solve(strings.NewReader(input), Options{Exhaustive: true}); !errors.Is(err, ErrNoPathSet) {
+		t.Errorf("exhaustive Solve error = %v; want ErrNoPathSet", err)
+	}
+}
+
+// TestSolveOneWayTunnels checks that ants only cross one-way tunnels in
+// their direction, written as a>b or after ##directed.
+func TestSolveOneWayTunnels(t *testing.T) {
+	const rooms = `2
+##start
+s 0 0
+a 1 0
+b 1 1
+c 2 1
+##end
+e 2 0
+`
+	tests := []struct {
+		name, links string
+	}{
+		{"a>b", "s-a\ne>a\ns-b\nb-c\nc-e\n"},
+		{"##directed", "##directed\ns-a\ne-a\ns-b\nb-c\nc-e\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			solution := solveAndVerify(t, rooms+test.links, Options{})
+			want := []string{"s", "b", "c", "e"}
+			if len(solution.Paths) != 1 || strings.Join(solution.Paths[0], " ") != strings.Join(want, " ") {
+				t.Errorf("paths %v; want [%v]", solution.Paths, want)
+			}
+		})
+	}
+}
@@ -1,25 +1,77 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"io"
+	"lem-in/lemin"
 	"lem-in/src"
 	"os"
+	"strings"
+	"time"
 )
 
 // main is the entry point of the program.
 func main() {
+	// Dispatch subcommands before parsing the solver flags
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "visualize" {
+		os.Exit(runVisualize(os.Args[2:]))
+	}
+
+	strict := flag.Bool("strict", false, "print only the input file, a blank line and the ant moves")
+	verbose := flag.Bool("v", false, "print the details of invalid input errors")
+	stats := flag.Bool("stats", false, "stream-parse and solve the file and print statistics only")
+	stream := flag.Bool("stream", false, "stream-parse the file, for very large maps, and print it followed by the ant moves")
+	capacity := flag.String("capacity", "both", "rooms and/or tunnels limited to one ant at a time: vertex, edge or both")
+	flag.Parse()
+
 	// Check if a file path is provided as a command-line argument
-	if len(os.Args) < 2 {
+	if flag.NArg() < 1 {
 		fmt.Println("Please provide a file path")
 		return
 	}
 
-	filePath := os.Args[1]
+	filePath := flag.Arg(0)
+
+	capacityModel, err := src.ParseCapacityModel(*capacity)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Parse very large files with the streaming parser and report how it went
+	if *stats {
+		printParseStats(filePath, capacityModel, *verbose)
+		return
+	}
+	if *stream {
+		solveStream(filePath, capacityModel, *verbose)
+		return
+	}
 
 	// Parse the input file and create a LemInData struct
 	lemInData, err := src.ParseInputFile(filePath)
 	if err != nil {
-		fmt.Println("Error parsing file:", err)
+		printError(err, *verbose)
+		return
+	}
+
+	// Select the optimal paths, distribute the ants and simulate their moves
+	solution, err := lemin.SolveData(lemInData, lemin.Options{Capacity: capacityModel})
+	if err != nil {
+		printError(err, *verbose)
+		return
+	}
+
+	// In strict mode, echo the input verbatim followed by the moves only
+	if *strict {
+		lemInData.WriteInput(os.Stdout)
+		fmt.Println()
+		src.WriteTurns(os.Stdout, solution.Turns)
 		return
 	}
 
@@ -28,21 +80,17 @@ func main() {
 
 	// Print parsed data for verification
 	fmt.Printf("Number of ants: %d\n", lemInData.NumAnts)
-	fmt.Printf("Start room: %s\n", lemInData.StartRoom)
-	fmt.Printf("End room: %s\n", lemInData.EndRoom)
+	fmt.Printf("Start room: %s\n", strings.Join(lemInData.Starts(), ", "))
+	fmt.Printf("End room: %s\n", strings.Join(lemInData.Ends(), ", "))
 	fmt.Printf("Name of ants: %s\n", lemInData.TabAntNames)
+	fmt.Println("Best paths: ", solution.Paths)
+	fmt.Printf("Number of turns: %d\n", len(solution.Turns))
+	fmt.Printf("Lower bound: %d turns (shortest path: %d turns, max flow: %d per turn)\n",
+		solution.Bound.Turns, solution.Bound.Shortest, solution.Bound.MaxFlow)
+	fmt.Printf("Optimality gap: %d turns\n", len(solution.Turns)-solution.Bound.Turns)
+	fmt.Println(solution.Assignment)
 	fmt.Println("Rooms:")
 
-	// Find all possible paths from start to end using DFS
-	allPaths := src.FindAllPathsBFS(lemInData.Rooms, lemInData.StartRoom, lemInData.EndRoom)
-
-	// Select the optimal paths for ant movement
-	BestPath := src.FilterPath(allPaths, lemInData.StartRoom, lemInData.EndRoom)
-	fmt.Println("Best paths: ", BestPath)
-
-	// Distribute ants among the selected paths
-	antDistribution := src.DistributeAnts(BestPath, lemInData.NumAnts)
-
 	// Print the input data (room information and links)
 	for _, room := range lemInData.Rooms {
 		if room.IsStart {
@@ -54,11 +102,85 @@ func main() {
 	}
 	for name, room := range lemInData.Rooms {
 		for _, link := range room.Links {
-			fmt.Printf("%s-%s\n", name, link)
+			if lemInData.OneWay(name, link) {
+				fmt.Printf("%s>%s\n", name, link)
+			} else {
+				fmt.Printf("%s-%s\n", name, link)
+			}
 		}
 	}
 	fmt.Println() // Empty line before ant movements
 
-	// Simulate and print ant movements
-	src.SimulateAntMovement(BestPath, antDistribution)
+	// Print ant movements
+	src.WriteTurns(os.Stdout, solution.Turns)
+}
+
+// printError prints the canonical lem-in error message, followed by the
+// details of err in verbose mode.
+func printError(err error, verbose bool) {
+	if verbose {
+		fmt.Println("ERROR: invalid data format,", err)
+		return
+	}
+	fmt.Println("ERROR: invalid data format")
+}
+
+// printParseStats parses filePath with the streaming parser and prints the
+// size of the colony and the time and memory it took, then solves it and
+// prints the number of turns and the time that took.
+func printParseStats(filePath string, capacity src.CapacityModel, verbose bool) {
+	colony, stats, err := src.ParseStreamFile(filePath)
+	if err != nil {
+		printError(err, verbose)
+		return
+	}
+	fmt.Printf("Number of ants: %d\n", colony.NumAnts)
+	fmt.Printf("Start room: %s\n", colony.Names[colony.Start])
+	fmt.Printf("End room: %s\n", colony.Names[colony.End])
+	fmt.Printf("Lines: %d (%d bytes)\n", stats.Lines, stats.Bytes)
+	fmt.Printf("Rooms: %d\n", stats.Rooms)
+	fmt.Printf("Links: %d\n", stats.Links)
+	fmt.Printf("Parse time: %s\n", stats.Duration)
+	fmt.Printf("Allocated: %.1f MiB (heap in use: %.1f MiB)\n", float64(stats.TotalAlloc)/(1<<20), float64(stats.HeapInUse)/(1<<20))
+
+	started := time.Now()
+	solution, err := lemin.SolveGraph(colony.Graph(), colony.NumAnts, lemin.Options{Capacity: capacity})
+	if err != nil {
+		printError(err, verbose)
+		return
+	}
+	fmt.Printf("Turns: %d (lower bound: %d)\n", len(solution.Turns), solution.Bound.Turns)
+	fmt.Printf("Solve time: %s\n", time.Since(started))
+}
+
+// solveStream solves filePath with the streaming parser and prints it like
+// the strict mode: the input file, a blank line and the ant moves. The file
+// is read a second time to echo it, rather than kept in memory.
+func solveStream(filePath string, capacity src.CapacityModel, verbose bool) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		printError(err, verbose)
+		return
+	}
+	defer file.Close()
+
+	solution, err := lemin.SolveStream(file, lemin.Options{Capacity: capacity})
+	if err != nil {
+		printError(err, verbose)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		printError(err, verbose)
+		return
+	}
+	out := bufio.NewWriter(os.Stdout)
+	size, _ := io.Copy(out, file)
+	// Like -strict, end the last input line before the blank line
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err == nil && last[0] != '\n' {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out)
+	out.Flush()
+	solution.WriteMoves(os.Stdout)
 }
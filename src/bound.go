@@ -0,0 +1,63 @@
+package src
+
+// Bound is a provable lower bound on the number of turns needed to move the
+// ants of a colony, with the quantities it is derived from.
+type Bound struct {
+	Turns    int // No schedule can finish in fewer turns
+	Shortest int // Turns needed to walk the shortest path from start to end (its number of moves when every tunnel takes one turn)
+	MaxFlow  int // Maximum number of ants reaching the end per turn under the capacity model (minimum cut), capped at the number of ants
+}
+
+// LowerBound computes a lower bound on the number of turns needed to move
+// numAnts ants from the start room to the end room. If at most f ants can
+// reach the end per turn (the size of a minimum cut under the graph's
+// capacity model and room capacities) and the shortest path takes d moves,
+// at most f*(T-d+1) ants can arrive within T turns, so every schedule needs
+// at least d + ceil(numAnts/f) - 1 turns. With several start rooms, the
+// bound of the start room needing the most turns for its own ants is
+// returned. It returns a zero Bound if an end room cannot be reached from a
+// start room holding ants.
+func (g *Graph) LowerBound(numAnts int) Bound {
+	starts, ants := g.starts(numAnts)
+	var bound Bound
+	for i, start := range starts {
+		if ants[i] == 0 {
+			continue
+		}
+		startBound := g.startBound(start, ants[i], numAnts)
+		if startBound.MaxFlow == 0 {
+			return Bound{}
+		}
+		if startBound.Turns > bound.Turns {
+			bound = startBound
+		}
+	}
+	return bound
+}
+
+// startBound computes the lower bound of the ants leaving from one start room.
+func (g *Graph) startBound(start, ants, numAnts int) Bound {
+	network, source, sink := g.newFlowNetwork([]int{start}, []int{ants}, numAnts, nil)
+
+	var bound Bound
+	for bound.MaxFlow < ants {
+		prev := network.shortestAugmentingPath(source, sink)
+		if prev == nil {
+			break
+		}
+		if bound.MaxFlow == 0 {
+			// The first augmenting path is a shortest path of the graph
+			for node := sink; node != source; node = network.edges[prev[node]^1].to {
+				bound.Shortest += network.edges[prev[node]].cost
+			}
+		}
+		network.augment(prev, source, sink)
+		bound.MaxFlow++
+	}
+	if bound.MaxFlow == 0 {
+		return Bound{}
+	}
+
+	bound.Turns = bound.Shortest + (ants+bound.MaxFlow-1)/bound.MaxFlow - 1
+	return bound
+}
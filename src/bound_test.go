@@ -0,0 +1,35 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLowerBound(t *testing.T) {
+	const blocking = "##start\ns 0 0\na 1 0\nb 2 0\nc 1 1\nx 2 1\nd 2 2\ny 3 2\n##end\ne 3 0\n" +
+		"s-a\na-b\nb-e\ns-c\nc-x\nx-b\na-d\nd-y\ny-e\n"
+	tests := []struct {
+		name  string
+		input string
+		want  Bound
+	}{
+		{"single tunnel", "3\n##start\ns 0 0\n##end\ne 1 0\ns-e\n", Bound{Turns: 3, Shortest: 1, MaxFlow: 1}},
+		{"long tunnel", "2\n##start\ns 0 0\n##end\ne 1 0\ns-e 3\n", Bound{Turns: 4, Shortest: 3, MaxFlow: 1}},
+		{"flow capped at the ants", "1\n" + blocking, Bound{Turns: 3, Shortest: 3, MaxFlow: 1}},
+		// The shortest path and the two disjoint paths cannot be used together,
+		// so 8 turns are needed where the bound says 7
+		{"shortest path blocking the flow", "10\n" + blocking, Bound{Turns: 7, Shortest: 3, MaxFlow: 2}},
+		{"no path", "1\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\n", Bound{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, err := ParseInput(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := NewGraph(l).LowerBound(l.NumAnts); got != test.want {
+				t.Errorf("LowerBound = %+v; want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package src
+
+import "fmt"
+
+// CapacityModel selects which parts of the colony are limited to one ant at
+// a time. It drives both path selection and simulation.
+type CapacityModel int
+
+const (
+	// VertexAndEdgeCapacity is the standard lem-in rule: an intermediate
+	// room holds one ant and each tunnel is used once per turn.
+	VertexAndEdgeCapacity CapacityModel = iota
+	// VertexCapacity limits rooms to one ant; a tunnel may be used by
+	// several ants in the same turn.
+	VertexCapacity
+	// EdgeCapacity limits each tunnel to one ant per turn; rooms hold any
+	// number of ants.
+	EdgeCapacity
+)
+
+// String returns the name of the model, as accepted by ParseCapacityModel.
+func (m CapacityModel) String() string {
+	switch m {
+	case VertexCapacity:
+		return "vertex"
+	case EdgeCapacity:
+		return "edge"
+	default:
+		return "both"
+	}
+}
+
+// ParseCapacityModel returns the model named "vertex", "edge" or "both".
+func ParseCapacityModel(name string) (CapacityModel, error) {
+	switch name {
+	case "both":
+		return VertexAndEdgeCapacity, nil
+	case "vertex":
+		return VertexCapacity, nil
+	case "edge":
+		return EdgeCapacity, nil
+	}
+	return 0, fmt.Errorf("unknown capacity model %q (want vertex, edge or both)", name)
+}
+
+// LimitsRooms reports whether intermediate rooms hold a single ant.
+func (m CapacityModel) LimitsRooms() bool {
+	return m != EdgeCapacity
+}
+
+// LimitsTunnels reports whether each tunnel is used once per turn.
+func (m CapacityModel) LimitsTunnels() bool {
+	return m != VertexCapacity
+}
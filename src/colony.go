@@ -1,10 +1,8 @@
 package src
 
 import (
-	"fmt"
-	"math"
+	"sort"
 	"strconv"
-	"strings"
 )
 
 // nameAnts generates names for all ants (L1, L2, ..., Ln).
@@ -17,63 +15,212 @@ func (l *LemInData) NameAnts() {
 
 // DistributeAnts assigns ants to paths to minimize the number of turns.
 func DistributeAnts(paths [][]string, numAnts int) [][]int {
-	distribution := make([][]int, len(paths))
-	pathLengths := make([]int, len(paths))
-	for i, path := range paths {
-		pathLengths[i] = len(path) - 1
-	}
-
-	// Distribute ants in a specific order
-	for i := 1; i <= numAnts; i++ {
-		bestPathIndex := 0
-		bestArrivalTime := math.MaxInt32
-		for j := range paths {
-			arrivalTime := len(distribution[j]) + pathLengths[j]
-			if arrivalTime < bestArrivalTime {
-				bestPathIndex = j
-				bestArrivalTime = arrivalTime
+	distribution, _ := DistributeByLength(nameLengths(paths), numAnts)
+	return distribution
+}
+
+// CountTurns returns the number of turns needed to move numAnts ants along
+// paths when they are distributed by DistributeAnts.
+func CountTurns(paths [][]string, numAnts int) int {
+	return CountTurnsByLength(nameLengths(paths), numAnts)
+}
+
+// CountTurnsByLength is CountTurns for paths given by their number of moves.
+func CountTurnsByLength(lengths []int, numAnts int) int {
+	_, turns := DistributionCounts(lengths, numAnts)
+	return turns
+}
+
+// DistributionCounts computes, without going through the ants one by one,
+// how many ants DistributeAnts sends along each path given its number of
+// moves, and the number of turns the last ant needs to reach the end room.
+//
+// The k-th ant (from 0) sent along a path of length L arrives at turn L+k,
+// and sending ants to the path where they arrive first amounts to keeping the
+// numAnts smallest arrival turns. The last arrival turn T is therefore the
+// smallest one for which numAnts ants can arrive by turn T; it is found by a
+// binary search over T using the sorted path lengths, in O(paths log paths).
+func DistributionCounts(pathLengths []int, numAnts int) ([]int, int) {
+	counts := make([]int, len(pathLengths))
+	if len(pathLengths) == 0 || numAnts < 1 {
+		return counts, 0
+	}
+
+	sorted := make([]int, len(pathLengths))
+	copy(sorted, pathLengths)
+	sort.Ints(sorted)
+	prefix := make([]int, len(sorted)+1)
+	for i, length := range sorted {
+		prefix[i+1] = prefix[i] + length
+	}
+
+	// arrivals returns how many ants can arrive by turn t
+	arrivals := func(t int) int {
+		usable := sort.SearchInts(sorted, t+1)
+		return usable*(t+1) - prefix[usable]
+	}
+
+	low, high := sorted[0], sorted[0]+numAnts-1
+	turns := low + sort.Search(high-low+1, func(i int) bool { return arrivals(low+i) >= numAnts })
+
+	// Every path gets one ant per turn before the last one, and the ants
+	// still missing arrive at the last turn on the first paths, in order
+	remaining := numAnts - arrivals(turns-1)
+	for j, length := range pathLengths {
+		if length < turns {
+			counts[j] = turns - length
+		}
+		if length <= turns && remaining > 0 {
+			counts[j]++
+			remaining--
+		}
+	}
+	return counts, turns
+}
+
+// DistributeByLength sends each ant, in order, to the path where it would
+// arrive first, given the number of moves of each path. It returns the ants
+// assigned to each path with the number of turns the last ant needs to
+// reach the end room. The number of ants per path comes from
+// DistributionCounts; the ants are then numbered in order of arrival, ties
+// going to the first path.
+func DistributeByLength(pathLengths []int, numAnts int) ([][]int, int) {
+	counts, turns := DistributionCounts(pathLengths, numAnts)
+	distribution := make([][]int, len(pathLengths))
+	if len(pathLengths) == 0 {
+		return distribution, 0
+	}
+	for j, count := range counts {
+		distribution[j] = make([]int, 0, count)
+	}
+
+	// Paths in order of length, then index, so that they can be activated
+	// as the arrival turn reaches their length
+	order := make([]int, len(pathLengths))
+	for j := range order {
+		order[j] = j
+	}
+	sort.SliceStable(order, func(a, b int) bool { return pathLengths[order[a]] < pathLengths[order[b]] })
+
+	var active []int // Paths already reached by the arrival turn, by index
+	next := 0
+	ant := 1
+	for arrival := pathLengths[order[0]]; ant <= numAnts && arrival <= turns; arrival++ {
+		for next < len(order) && pathLengths[order[next]] <= arrival {
+			position := sort.SearchInts(active, order[next])
+			active = append(active, 0)
+			copy(active[position+1:], active[position:])
+			active[position] = order[next]
+			next++
+		}
+		for _, j := range active {
+			if len(distribution[j]) < counts[j] {
+				distribution[j] = append(distribution[j], ant)
+				ant++
 			}
 		}
-		distribution[bestPathIndex] = append(distribution[bestPathIndex], i)
 	}
-	fmt.Println(distribution)
-	return distribution
+	return distribution, turns
 }
 
-// SimulateAntMovement simulates and prints the movement of ants through the colony.
-func SimulateAntMovement(paths [][]string, antDistribution [][]int) {
-	type AntPosition struct {
-		ant  int
-		path int
-		step int
-	}
-	var antPositions []AntPosition
-	for pathIndex, ants := range antDistribution {
-		for _, ant := range ants {
-			antPositions = append(antPositions, AntPosition{ant, pathIndex, 0})
+// CountTurns returns the number of turns needed to move numAnts ants along
+// paths of room IDs. With several start rooms, the ants of each start room
+// use the paths leaving from it and the slowest start room sets the number
+// of turns. It returns 0 if a start room holding ants has no path.
+func (g *Graph) CountTurns(paths [][]int, numAnts int) int {
+	if g.Starts == nil {
+		return CountTurnsByLength(g.PathLengths(paths), numAnts)
+	}
+
+	turns := 0
+	for _, group := range g.pathsByStart(paths, numAnts) {
+		if group.ants == 0 {
+			continue
+		}
+		if len(group.paths) == 0 {
+			return 0
 		}
+		turns = max(turns, CountTurnsByLength(g.groupLengths(paths, group), group.ants))
+	}
+	return turns
+}
+
+// Distribute assigns the ants to paths of room IDs and returns the ants
+// assigned to each path with the number of turns the last ant needs to reach
+// an end room. The ants of each start room, numbered start room by start
+// room, are distributed between the paths leaving from it by
+// DistributeByLength.
+func (g *Graph) Distribute(paths [][]int, numAnts int) ([][]int, int) {
+	if g.Starts == nil {
+		return DistributeByLength(g.PathLengths(paths), numAnts)
 	}
-	for len(antPositions) > 0 {
-		var moves []string
-		var newPositions []AntPosition
-		usedLinks := make(map[string]bool)
-		for _, pos := range antPositions {
-			if pos.step < len(paths[pos.path])-1 {
-				currentRoom := paths[pos.path][pos.step]
-				nextRoom := paths[pos.path][pos.step+1]
-				link := currentRoom + "-" + nextRoom
-				if !usedLinks[link] {
-					moves = append(moves, fmt.Sprintf("L%d-%s", pos.ant, nextRoom))
-					newPositions = append(newPositions, AntPosition{pos.ant, pos.path, pos.step + 1})
-					usedLinks[link] = true
-				} else {
-					newPositions = append(newPositions, pos)
-				}
+
+	distribution := make([][]int, len(paths))
+	turns, firstAnt := 0, 0
+	for _, group := range g.pathsByStart(paths, numAnts) {
+		if group.ants == 0 {
+			continue
+		}
+		ants, groupTurns := DistributeByLength(g.groupLengths(paths, group), group.ants)
+		for j, path := range group.paths {
+			for _, ant := range ants[j] {
+				distribution[path] = append(distribution[path], firstAnt+ant)
 			}
 		}
-		if len(moves) > 0 {
-			fmt.Println(strings.Join(moves, " "))
+		turns = max(turns, groupTurns)
+		firstAnt += group.ants
+	}
+	return distribution, turns
+}
+
+// startGroup holds the ants leaving from a start room and the indexes of
+// the paths leaving from it.
+type startGroup struct {
+	ants  int
+	paths []int
+}
+
+// pathsByStart groups the indexes of paths by start room, in the order of
+// the graph's start rooms.
+func (g *Graph) pathsByStart(paths [][]int, numAnts int) []startGroup {
+	starts, ants := g.starts(numAnts)
+	groups := make([]startGroup, len(starts))
+	index := make(map[int]int, len(starts))
+	for i, start := range starts {
+		groups[i].ants = ants[i]
+		index[start] = i
+	}
+	for j, path := range paths {
+		if i, ok := index[path[0]]; ok {
+			groups[i].paths = append(groups[i].paths, j)
 		}
-		antPositions = newPositions
 	}
+	return groups
+}
+
+// groupLengths returns the number of turns needed to walk each path of a group.
+func (g *Graph) groupLengths(paths [][]int, group startGroup) []int {
+	lengths := make([]int, len(group.paths))
+	for j, path := range group.paths {
+		lengths[j] = g.PathLength(paths[path])
+	}
+	return lengths
+}
+
+// PathLengths returns the number of moves along each path of room IDs.
+func PathLengths(paths [][]int) []int {
+	lengths := make([]int, len(paths))
+	for i, path := range paths {
+		lengths[i] = len(path) - 1
+	}
+	return lengths
+}
+
+// nameLengths returns the number of moves along each path of room names.
+func nameLengths(paths [][]string) []int {
+	lengths := make([]int, len(paths))
+	for i, path := range paths {
+		lengths[i] = len(path) - 1
+	}
+	return lengths
 }
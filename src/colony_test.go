@@ -0,0 +1,71 @@
+package src
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// greedyDistribution is the ant-by-ant distribution DistributeByLength
+// replaced: each ant, in order, goes to the path where it arrives first, ties
+// going to the first path.
+func greedyDistribution(pathLengths []int, numAnts int) ([][]int, int) {
+	distribution := make([][]int, len(pathLengths))
+	for j := range distribution {
+		distribution[j] = []int{}
+	}
+	turns := 0
+	for ant := 1; ant <= numAnts; ant++ {
+		best, bestArrival := 0, math.MaxInt32
+		for j, length := range pathLengths {
+			if arrival := len(distribution[j]) + length; arrival < bestArrival {
+				best, bestArrival = j, arrival
+			}
+		}
+		distribution[best] = append(distribution[best], ant)
+		turns = max(turns, bestArrival)
+	}
+	return distribution, turns
+}
+
+func TestDistributeByLengthMatchesGreedy(t *testing.T) {
+	tests := []struct {
+		name    string
+		lengths []int
+		ants    int
+	}{
+		{"single path", []int{3}, 5},
+		{"one ant", []int{2, 2, 4}, 1},
+		{"equal paths", []int{2, 2, 2}, 7},
+		{"unsorted paths", []int{5, 1, 3}, 10},
+		{"long path never used", []int{1, 2, 20}, 4},
+		{"ties at the last turn", []int{4, 3, 3, 6}, 9},
+		{"many ants", []int{7, 2, 9, 2, 5}, 1000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotTurns := DistributeByLength(test.lengths, test.ants)
+			want, wantTurns := greedyDistribution(test.lengths, test.ants)
+			if gotTurns != wantTurns || !reflect.DeepEqual(got, want) {
+				t.Errorf("DistributeByLength(%v, %d) = %v, %d; want %v, %d", test.lengths, test.ants, got, gotTurns, want, wantTurns)
+			}
+		})
+	}
+}
+
+func TestDistributeByLengthMatchesGreedyRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		lengths := make([]int, 1+r.Intn(6))
+		for j := range lengths {
+			lengths[j] = 1 + r.Intn(10)
+		}
+		ants := 1 + r.Intn(50)
+		got, gotTurns := DistributeByLength(lengths, ants)
+		want, wantTurns := greedyDistribution(lengths, ants)
+		if gotTurns != wantTurns || !reflect.DeepEqual(got, want) {
+			t.Fatalf("DistributeByLength(%v, %d) = %v, %d; want %v, %d", lengths, ants, got, gotTurns, want, wantTurns)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package src
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NoPathError reports that the end room cannot be reached from the start
+// room, with the connected component each of them lives in. Both rooms are
+// in the same component when one-way tunnels block every path between them.
+type NoPathError struct {
+	Components     int      // Number of connected components in the colony
+	StartComponent int      // Index of the component holding the start room
+	EndComponent   int      // Index of the component holding the end room
+	StartRooms     []string // Rooms of the start room's component, sorted by name
+	EndRooms       []string // Rooms of the end room's component, sorted by name
+}
+
+func (e *NoPathError) Error() string {
+	if e.StartComponent == e.EndComponent {
+		return fmt.Sprintf("%s: start and end rooms are in component %d of %d (%s), but one-way tunnels block every path",
+			ErrNoPath, e.StartComponent, e.Components, roomList(e.StartRooms))
+	}
+	return fmt.Sprintf("%s: start room is in component %d of %d (%s), end room is in component %d (%s)",
+		ErrNoPath, e.StartComponent, e.Components, roomList(e.StartRooms), e.EndComponent, roomList(e.EndRooms))
+}
+
+// roomList formats a list of rooms for an error message, eliding long lists.
+func roomList(rooms []string) string {
+	const maxRooms = 10
+	if len(rooms) > maxRooms {
+		return fmt.Sprintf("%d rooms: %s ...", len(rooms), strings.Join(rooms[:maxRooms], " "))
+	}
+	return fmt.Sprintf("%d rooms: %s", len(rooms), strings.Join(rooms, " "))
+}
+
+// Unwrap returns ErrNoPath, so that errors.Is(err, ErrNoPath) works.
+func (e *NoPathError) Unwrap() error {
+	return ErrNoPath
+}
+
+// Components labels every room with the index of its connected component,
+// ignoring the direction of one-way tunnels. Components are numbered from 0,
+// in the order of their first room name.
+func (l *LemInData) Components() map[string]int {
+	names := make([]string, 0, len(l.Rooms))
+	for name := range l.Rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// One-way tunnels are only listed in the Links of the room they leave
+	reverse := make(map[string][]string)
+	for _, name := range names {
+		for _, link := range l.Rooms[name].Links {
+			if !Contains(l.Rooms[link].Links, name) {
+				reverse[link] = append(reverse[link], name)
+			}
+		}
+	}
+
+	component := make(map[string]int, len(names))
+	count := 0
+	for _, name := range names {
+		if _, seen := component[name]; seen {
+			continue
+		}
+		component[name] = count
+		queue := []string{name}
+		for len(queue) > 0 {
+			room := queue[0]
+			queue = queue[1:]
+			for _, links := range [][]string{l.Rooms[room].Links, reverse[room]} {
+				for _, link := range links {
+					if _, seen := component[link]; !seen {
+						component[link] = count
+						queue = append(queue, link)
+					}
+				}
+			}
+		}
+		count++
+	}
+	return component
+}
+
+// CheckConnectivity returns a *NoPathError if no end room can be reached
+// from a start room holding ants. The error describes the first such start
+// room and the first end room; the components are only computed then.
+func (l *LemInData) CheckConnectivity() error {
+	for _, name := range l.Starts() {
+		if l.AntsAt(name) > 0 && !l.reachesEnd(name) {
+			component := l.Components()
+			end := component[l.EndRoom]
+			for _, other := range l.Ends() {
+				if component[other] == component[name] {
+					end = component[other]
+				}
+			}
+			return newNoPathError(component, component[name], end)
+		}
+	}
+	return nil
+}
+
+// reachesEnd reports whether an end room can be reached from a room,
+// following one-way tunnels in their direction only.
+func (l *LemInData) reachesEnd(start string) bool {
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		room := queue[0]
+		queue = queue[1:]
+		if l.isEnd(room) {
+			return true
+		}
+		for _, link := range l.Rooms[room].Links {
+			if !seen[link] {
+				seen[link] = true
+				queue = append(queue, link)
+			}
+		}
+	}
+	return false
+}
+
+// newNoPathError describes the components of a start room and an end room
+// that are not connected.
+func newNoPathError(component map[string]int, start, end int) *NoPathError {
+	err := &NoPathError{StartComponent: start, EndComponent: end}
+	for name, c := range component {
+		if c+1 > err.Components {
+			err.Components = c + 1
+		}
+		if c == start {
+			err.StartRooms = append(err.StartRooms, name)
+		} else if c == end {
+			err.EndRooms = append(err.EndRooms, name)
+		}
+	}
+	sort.Strings(err.StartRooms)
+	sort.Strings(err.EndRooms)
+	return err
+}
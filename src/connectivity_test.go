@@ -0,0 +1,49 @@
+package src
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckConnectivity(t *testing.T) {
+	const rooms = "1\n##start\ns 0 0\na 1 0\nb 2 0\nc 3 0\n##end\ne 4 0\n"
+	tests := []struct {
+		name  string
+		links string
+		want  *NoPathError // nil if the end room is reachable
+	}{
+		{"connected", "s-a\na-b\nb-e\n", nil},
+		{"disconnected", "s-a\nb-e\n", &NoPathError{
+			Components: 3, StartComponent: 0, EndComponent: 1,
+			StartRooms: []string{"a", "s"}, EndRooms: []string{"b", "e"},
+		}},
+		{"blocked by one-way tunnels", "a>s\na-b\nb-e\nc-e\n", &NoPathError{
+			Components: 1, StartComponent: 0, EndComponent: 0,
+			StartRooms: []string{"a", "b", "c", "e", "s"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, err := ParseInput(strings.NewReader(rooms + test.links))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = l.CheckConnectivity()
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("CheckConnectivity = %v; want nil", err)
+				}
+				return
+			}
+			var noPath *NoPathError
+			if !errors.As(err, &noPath) || !errors.Is(err, ErrNoPath) {
+				t.Fatalf("CheckConnectivity = %v; want a *NoPathError", err)
+			}
+			if !reflect.DeepEqual(noPath, test.want) {
+				t.Errorf("CheckConnectivity = %+v; want %+v", noPath, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of problems reported while parsing or solving a colony. Use
+// errors.Is to test for them, and errors.As to get the *ParseError.
+var (
+	ErrInvalidAnts       = errors.New("invalid number of ants")
+	ErrNoStart           = errors.New("start room not defined")
+	ErrNoEnd             = errors.New("end room not defined")
+	ErrCommandNoRoom     = errors.New("command must be followed by a room")
+	ErrAntsNoStart       = errors.New("##ants must be followed by a start room")
+	ErrAntsMismatch      = errors.New("ants of the start rooms do not add up to the number of ants")
+	ErrInvalidRoom       = errors.New("invalid room definition")
+	ErrInvalidRoomName   = errors.New("invalid room name")
+	ErrInvalidCoordinate = errors.New("invalid coordinate")
+	ErrInvalidCapacity   = errors.New("invalid room capacity")
+	ErrDuplicateRoom     = errors.New("duplicate room")
+	ErrInvalidLink       = errors.New("invalid link definition")
+	ErrInvalidWeight     = errors.New("invalid tunnel length")
+	ErrSelfLink          = errors.New("room cannot link to itself")
+	ErrUnknownRoom       = errors.New("link to unknown room")
+	ErrDuplicateLink     = errors.New("duplicate link")
+	ErrInvalidLine       = errors.New("invalid line")
+	ErrNoPath            = errors.New("no path from start room to end room")
+)
+
+// ParseError reports a problem found while parsing a colony description.
+type ParseError struct {
+	Line   int    // Line number in the input, starting at 1 (0 if not tied to a line)
+	Column int    // Column of the offending field, starting at 1 (0 for the whole line)
+	Kind   error  // One of the Err* errors above
+	Text   string // Offending line
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line == 0 && e.Text == "":
+		return e.Kind.Error()
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %q", e.Kind, e.Text)
+	case e.Column == 0:
+		return fmt.Sprintf("line %d: %s: %q", e.Line, e.Kind, e.Text)
+	default:
+		return fmt.Sprintf("line %d, column %d: %s: %q", e.Line, e.Column, e.Kind, e.Text)
+	}
+}
+
+// Unwrap returns the kind of the error, so that errors.Is(err, ErrDuplicateRoom) works.
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
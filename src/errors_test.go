@@ -0,0 +1,46 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorFormat(t *testing.T) {
+	tests := []struct {
+		err  *ParseError
+		want string
+	}{
+		{&ParseError{Kind: ErrNoStart}, "start room not defined"},
+		{&ParseError{Kind: ErrAntsMismatch, Text: "3"}, `ants of the start rooms do not add up to the number of ants: "3"`},
+		{&ParseError{Line: 4, Kind: ErrInvalidLine, Text: "a_b"}, `line 4: invalid line: "a_b"`},
+		{&ParseError{Line: 7, Column: 3, Kind: ErrUnknownRoom, Text: "a-z"}, `line 7, column 3: link to unknown room: "a-z"`},
+	}
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("Error() = %q; want %q", got, test.want)
+		}
+	}
+}
+
+// TestParseErrorIsAs checks that a parse error can be told apart by its kind
+// with errors.Is and unpacked with errors.As, also when wrapped.
+func TestParseErrorIsAs(t *testing.T) {
+	_, err := ParseInput(strings.NewReader("2\n##start\ns 0 0\n##end\ne 1 0\ns 2 2\n"))
+	wrapped := fmt.Errorf("reading colony: %w", err)
+
+	if !errors.Is(wrapped, ErrDuplicateRoom) {
+		t.Errorf("errors.Is(%v, ErrDuplicateRoom) = false", wrapped)
+	}
+	if errors.Is(wrapped, ErrDuplicateLink) {
+		t.Errorf("errors.Is(%v, ErrDuplicateLink) = true", wrapped)
+	}
+	var parseErr *ParseError
+	if !errors.As(wrapped, &parseErr) {
+		t.Fatalf("errors.As(%v, *ParseError) = false", wrapped)
+	}
+	if parseErr.Line != 6 || parseErr.Column != 1 || parseErr.Text != "s 2 2" {
+		t.Errorf("ParseError = line %d, column %d, text %q; want line 6, column 1, text %q", parseErr.Line, parseErr.Column, parseErr.Text, "s 2 2")
+	}
+}
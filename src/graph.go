@@ -0,0 +1,294 @@
+package src
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Graph is the colony with rooms numbered by dense integer IDs, which is
+// what the pathfinding, distribution and simulation code works on.
+type Graph struct {
+	Names []string       // Room names, indexed by room ID
+	IDs   map[string]int // Room IDs, keyed by room name
+	Adj   [][]int        // IDs of the rooms linked to each room
+	Start int            // ID of the start room (the first one when there are several)
+	End   int            // ID of the end room (the first one when there are several)
+
+	// Starts and Ends hold the IDs of every start and end room, and
+	// StartAnts the ants leaving from each room of Starts. They are nil for a
+	// colony with one start room, where every ant leaves from Start, and one
+	// end room.
+	Starts    []int
+	Ends      []int
+	StartAnts []int
+
+	// Capacities holds the number of ants each room can hold at once,
+	// indexed by room ID. A nil slice means every room holds one ant.
+	Capacities []int
+
+	// Weights holds the number of turns needed to cross each tunnel of Adj.
+	// A nil slice means every tunnel takes one turn.
+	Weights [][]int
+
+	// Capacity selects the rules applied by path selection and simulation
+	Capacity CapacityModel
+}
+
+// NewGraph builds the integer-indexed graph of a colony. Rooms are numbered
+// in name order so that results do not depend on map iteration order.
+func NewGraph(l *LemInData) *Graph {
+	names := make([]string, 0, len(l.Rooms))
+	for name := range l.Rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g := &Graph{
+		Names: names,
+		IDs:   make(map[string]int, len(names)),
+		Adj:   make([][]int, len(names)),
+		Start: -1,
+		End:   -1,
+
+		Capacities: make([]int, len(names)),
+	}
+	for id, name := range names {
+		g.IDs[name] = id
+		g.Capacities[id] = max(l.Rooms[name].Capacity, 1)
+	}
+	for id, name := range names {
+		room := l.Rooms[name]
+		for _, link := range room.Links {
+			if to, ok := g.IDs[link]; ok {
+				g.Adj[id] = append(g.Adj[id], to)
+			}
+		}
+		if len(room.Weights) > 0 && g.Weights == nil {
+			g.Weights = make([][]int, len(names))
+		}
+	}
+	if g.Weights != nil {
+		for id, links := range g.Adj {
+			g.Weights[id] = make([]int, len(links))
+			for i, to := range links {
+				g.Weights[id][i] = l.LinkWeight(names[id], names[to])
+			}
+		}
+	}
+	if id, ok := g.IDs[l.StartRoom]; ok {
+		g.Start = id
+	}
+	if id, ok := g.IDs[l.EndRoom]; ok {
+		g.End = id
+	}
+	if len(l.StartRooms) > 1 || len(l.EndRooms) > 1 || l.StartAnts != nil {
+		for _, start := range l.Starts() {
+			g.Starts = append(g.Starts, g.IDs[start])
+			g.StartAnts = append(g.StartAnts, l.AntsAt(start))
+		}
+		for _, end := range l.Ends() {
+			g.Ends = append(g.Ends, g.IDs[end])
+		}
+	}
+	return g
+}
+
+// Graph builds the integer-indexed graph of a colony read by ParseStream,
+// keeping its room IDs.
+func (c *CompactColony) Graph() *Graph {
+	g := &Graph{
+		Names: c.Names,
+		IDs:   make(map[string]int, len(c.Names)),
+		Adj:   make([][]int, len(c.Names)),
+		Start: int(c.Start),
+		End:   int(c.End),
+
+		Capacities: c.Capacities,
+		StartAnts:  c.StartAnts,
+	}
+	if len(c.Starts) > 1 || len(c.Ends) > 1 || c.StartAnts != nil {
+		for _, id := range c.Starts {
+			g.Starts = append(g.Starts, int(id))
+		}
+		for _, id := range c.Ends {
+			g.Ends = append(g.Ends, int(id))
+		}
+		if g.StartAnts == nil {
+			g.StartAnts = make([]int, len(g.Starts))
+			g.StartAnts[0] = c.NumAnts
+		}
+	}
+	if c.Weights != nil {
+		g.Weights = make([][]int, len(c.Names))
+	}
+	for id, name := range c.Names {
+		g.IDs[name] = id
+		for i, to := range c.Neighbors(int32(id)) {
+			g.Adj[id] = append(g.Adj[id], int(to))
+			if c.Weights != nil {
+				g.Weights[id] = append(g.Weights[id], int(c.Weights[c.Offsets[id]+int32(i)]))
+			}
+		}
+	}
+	return g
+}
+
+// starts returns the IDs of the start rooms with the number of ants leaving
+// from each of them.
+func (g *Graph) starts(numAnts int) ([]int, []int) {
+	if g.Starts == nil {
+		return []int{g.Start}, []int{numAnts}
+	}
+	return g.Starts, g.StartAnts
+}
+
+// ends returns the IDs of the end rooms.
+func (g *Graph) ends() []int {
+	if g.Ends == nil {
+		return []int{g.End}
+	}
+	return g.Ends
+}
+
+// terminals reports, for each room, whether it is a start or end room. Those
+// rooms hold any number of ants, wherever they appear along a path.
+func (g *Graph) terminals() []bool {
+	terminal := make([]bool, len(g.Names))
+	starts, _ := g.starts(0)
+	for _, id := range append(g.ends()[:len(g.ends()):len(g.ends())], starts...) {
+		if id >= 0 {
+			terminal[id] = true
+		}
+	}
+	return terminal
+}
+
+// distancesToEnd returns, for each room, the number of turns needed to reach
+// the nearest end room following the tunnels in their direction, or -1 if
+// no end room can be reached.
+func (g *Graph) distancesToEnd() []int {
+	reverse := make([][]int, len(g.Names))
+	for from, links := range g.Adj {
+		for _, to := range links {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+
+	dist := make([]int, len(g.Names))
+	for id := range dist {
+		dist[id] = -1
+	}
+	queue := &nodeQueue{}
+	for _, id := range g.ends() {
+		if id >= 0 {
+			dist[id] = 0
+			heap.Push(queue, queuedNode{node: id})
+		}
+	}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queuedNode)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		for _, from := range reverse[item.node] {
+			d := item.dist + g.Weight(from, item.node)
+			if dist[from] < 0 || d < dist[from] {
+				dist[from] = d
+				heap.Push(queue, queuedNode{node: from, dist: d})
+			}
+		}
+	}
+	return dist
+}
+
+// RoomCapacity returns the number of ants room id can hold at once.
+func (g *Graph) RoomCapacity(id int) int {
+	if g.Capacities == nil {
+		return 1
+	}
+	return g.Capacities[id]
+}
+
+// Weight returns the number of turns needed to cross the tunnel from room
+// from to room to.
+func (g *Graph) Weight(from, to int) int {
+	if g.Weights == nil {
+		return 1
+	}
+	for i, id := range g.Adj[from] {
+		if id == to {
+			return g.Weights[from][i]
+		}
+	}
+	return 1
+}
+
+// PathLength returns the number of turns an ant needs to walk path.
+func (g *Graph) PathLength(path []int) int {
+	if g.Weights == nil {
+		return len(path) - 1
+	}
+	length := 0
+	for i := 1; i < len(path); i++ {
+		length += g.Weight(path[i-1], path[i])
+	}
+	return length
+}
+
+// PathLengths returns the number of turns an ant needs to walk each path,
+// which is the number of moves when every tunnel takes one turn.
+func (g *Graph) PathLengths(paths [][]int) []int {
+	lengths := make([]int, len(paths))
+	for i, path := range paths {
+		lengths[i] = g.PathLength(path)
+	}
+	return lengths
+}
+
+// PathNames converts a path of room IDs into room names.
+func (g *Graph) PathNames(path []int) []string {
+	names := make([]string, len(path))
+	for i, id := range path {
+		names[i] = g.Names[id]
+	}
+	return names
+}
+
+// PathsNames converts paths of room IDs into room names.
+func (g *Graph) PathsNames(paths [][]int) [][]string {
+	names := make([][]string, len(paths))
+	for i, path := range paths {
+		names[i] = g.PathNames(path)
+	}
+	return names
+}
+
+// pathGraph numbers the rooms appearing in paths, for the functions that
+// receive room-name paths without a Graph. It returns the paths as IDs along
+// with a Graph holding only the name<->ID tables.
+func pathGraph(paths [][]string, start, end string) ([][]int, *Graph) {
+	g := &Graph{IDs: make(map[string]int), Start: -1, End: -1}
+	id := func(name string) int {
+		if id, ok := g.IDs[name]; ok {
+			return id
+		}
+		g.IDs[name] = len(g.Names)
+		g.Names = append(g.Names, name)
+		return len(g.Names) - 1
+	}
+
+	idPaths := make([][]int, len(paths))
+	for i, path := range paths {
+		idPaths[i] = make([]int, len(path))
+		for j, name := range path {
+			idPaths[i][j] = id(name)
+		}
+	}
+	if start != "" {
+		g.Start = id(start)
+	}
+	if end != "" {
+		g.End = id(end)
+	}
+	return idPaths, g
+}
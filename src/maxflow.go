@@ -0,0 +1,291 @@
+package src
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// flowEdge is an arc of the residual network used by the max-flow path selector.
+type flowEdge struct {
+	to   int // Index of the node this arc points to
+	cap  int // Remaining capacity of the arc
+	cost int // Cost of sending one unit of flow through the arc
+	orig int // Capacity the arc was created with (0 for reverse arcs)
+}
+
+// flowNetwork is a node-split residual network: every room i becomes an
+// "in" node (2*i) and an "out" node (2*i+1) joined by an arc of capacity 1,
+// so that no two paths can share an intermediate room. A super-source feeds
+// the start rooms and a super-sink drains the end rooms.
+type flowNetwork struct {
+	edges     []flowEdge
+	adj       [][]int
+	potential []int // Johnson potentials keeping reduced arc costs non-negative
+}
+
+func newFlowNetwork(nodes int) *flowNetwork {
+	return &flowNetwork{adj: make([][]int, nodes), potential: make([]int, nodes)}
+}
+
+// addEdge adds an arc and its zero-capacity reverse arc. Arc e and its
+// reverse are always stored at indexes e and e^1.
+func (n *flowNetwork) addEdge(from, to, capacity, cost int) {
+	n.adj[from] = append(n.adj[from], len(n.edges))
+	n.edges = append(n.edges, flowEdge{to: to, cap: capacity, cost: cost, orig: capacity})
+	n.adj[to] = append(n.adj[to], len(n.edges))
+	n.edges = append(n.edges, flowEdge{to: from, cap: 0, cost: -cost})
+}
+
+// shortestAugmentingPath finds the cheapest path with free capacity from
+// source to sink and returns the arc used to reach each node, or nil if the
+// sink cannot be reached. Reverse arcs carry negative costs, so Dijkstra runs
+// on costs reduced by the node potentials, which are then updated with the
+// new distances, capped at the sink's (Suurballe / Johnson). All arc costs
+// must be non-negative before the first call.
+func (n *flowNetwork) shortestAugmentingPath(source, sink int) []int {
+	const inf = int(^uint(0) >> 1)
+	dist := make([]int, len(n.adj))
+	prev := make([]int, len(n.adj))
+	for i := range dist {
+		dist[i] = inf
+		prev[i] = -1
+	}
+	dist[source] = 0
+	queue := &nodeQueue{{node: source}}
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(queuedNode)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		if item.node == sink {
+			// Nodes further than the sink cannot shorten the path
+			break
+		}
+		for _, e := range n.adj[item.node] {
+			edge := n.edges[e]
+			if edge.cap <= 0 {
+				continue
+			}
+			reduced := dist[item.node] + edge.cost + n.potential[item.node] - n.potential[edge.to]
+			if reduced < dist[edge.to] {
+				dist[edge.to] = reduced
+				prev[edge.to] = e
+				heap.Push(queue, queuedNode{node: edge.to, dist: reduced})
+			}
+		}
+	}
+
+	if dist[sink] == inf {
+		return nil
+	}
+	for node, d := range dist {
+		n.potential[node] += min(d, dist[sink])
+	}
+	return prev
+}
+
+// queuedNode is a node waiting in the Dijkstra priority queue.
+type queuedNode struct {
+	node int
+	dist int
+}
+
+// nodeQueue is a min-heap of nodes ordered by distance, for container/heap.
+type nodeQueue []queuedNode
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(queuedNode)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// augment pushes one unit of flow along the path recorded in prev.
+func (n *flowNetwork) augment(prev []int, source, sink int) {
+	for node := sink; node != source; {
+		e := prev[node]
+		n.edges[e].cap--
+		n.edges[e^1].cap++
+		node = n.edges[e^1].to
+	}
+}
+
+// extractPaths decomposes the current flow into paths of room IDs from
+// the start rooms to the end rooms, sorted from shortest to longest.
+func (n *flowNetwork) extractPaths(source, sink int) [][]int {
+	used := make([]int, len(n.edges))
+	var paths [][]int
+
+	for _, first := range n.adj[source] {
+		// An arc carrying several units of flow starts as many paths
+		for n.edges[first].orig-n.edges[first].cap-used[first] > 0 {
+			used[first]++
+			paths = append(paths, n.extractPath(first, sink, used))
+		}
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+	return paths
+}
+
+// extractPath follows one unit of flow from the arc first, which leads to
+// the "out" node of a start room, to the sink, counting the flow it consumes
+// in used.
+func (n *flowNetwork) extractPath(first, sink int, used []int) []int {
+	node := n.edges[first].to
+	path := []int{node / 2}
+	for node != sink {
+		if node%2 == 0 {
+			path = append(path, node/2)
+		}
+		for _, e := range n.adj[node] {
+			edge := n.edges[e]
+			if edge.orig > 0 && edge.orig-edge.cap-used[e] > 0 {
+				used[e]++
+				node = edge.to
+				break
+			}
+		}
+	}
+	return path
+}
+
+// FindOptimalPaths selects a set of vertex-disjoint paths from start to end
+// for numAnts ants and returns it along with the number of turns it takes.
+// It replaces the FindAllPathsBFS + FilterPath pipeline, which enumerates
+// every simple path. See Graph.OptimalPaths.
+func FindOptimalPaths(rooms map[string]*Room, start, end string, numAnts int) ([][]string, int) {
+	g := NewGraph(&LemInData{Rooms: rooms, StartRoom: start, EndRoom: end})
+	if g.Start < 0 || g.End < 0 {
+		return nil, 0
+	}
+	paths, turns := g.OptimalPaths(numAnts)
+	return g.PathsNames(paths), turns
+}
+
+// OptimalPaths selects a set of paths from the start room to the end room
+// that respects the graph's capacity model (vertex-disjoint by default), using
+// min-cost augmenting paths on a node-split flow network.
+// After each augmentation the resulting path set is evaluated for numAnts
+// ants and the one finishing in the fewest turns is returned along with that
+// turn count. When several start rooms cannot all get a path at once, because
+// they share a bottleneck, it falls back to SharedPaths.
+func (g *Graph) OptimalPaths(numAnts int) ([][]int, int) {
+	starts, ants := g.starts(numAnts)
+	network, source, sink := g.newFlowNetwork(starts, ants, numAnts, nil)
+	paths, turns := g.selectPaths(network, source, sink, numAnts, func(paths [][]int) int {
+		return g.CountTurns(paths, numAnts)
+	})
+	if paths == nil && g.Starts != nil {
+		return g.SharedPaths(numAnts)
+	}
+	return paths, turns
+}
+
+// SharedPaths selects paths for colonies whose start rooms have to share
+// rooms or tunnels one after the other. Each start room gets its own set of
+// paths, chosen like OptimalPaths for its ants alone, and the ants of
+// different start rooms take turns where these paths meet. The paths only
+// use tunnels leading strictly closer to an end room, so an ant waiting for a
+// room always waits for ants nearer the end and the ants cannot block each
+// other for good. The returned turn count is the length of the simulated
+// schedule; it is 0 if a start room holding ants cannot reach an end room.
+func (g *Graph) SharedPaths(numAnts int) ([][]int, int) {
+	dist := g.distancesToEnd()
+	downhill := func(from, to int) bool { return dist[to] >= 0 && dist[to] < dist[from] }
+
+	starts, ants := g.starts(numAnts)
+	var paths [][]int
+	for i, start := range starts {
+		if ants[i] == 0 {
+			continue
+		}
+		network, source, sink := g.newFlowNetwork([]int{start}, []int{ants[i]}, numAnts, downhill)
+		group, _ := g.selectPaths(network, source, sink, ants[i], func(paths [][]int) int {
+			return CountTurnsByLength(g.PathLengths(paths), ants[i])
+		})
+		if group == nil {
+			return nil, 0
+		}
+		paths = append(paths, group...)
+	}
+	if len(paths) == 0 {
+		return nil, 0
+	}
+
+	distribution, _ := g.Distribute(paths, numAnts)
+	return paths, len(g.Simulate(paths, distribution))
+}
+
+// selectPaths augments the flow of network one unit at a time, up to
+// maxFlow units, and returns the path set for which turns returns the
+// smallest positive number, with that number.
+func (g *Graph) selectPaths(network *flowNetwork, source, sink, maxFlow int, turns func([][]int) int) ([][]int, int) {
+	var bestPaths [][]int
+	bestTurns := 0
+	for flow := 0; flow < maxFlow; flow++ {
+		prev := network.shortestAugmentingPath(source, sink)
+		if prev == nil {
+			break
+		}
+		network.augment(prev, source, sink)
+
+		paths := network.extractPaths(source, sink)
+		if g.Weights != nil {
+			sort.SliceStable(paths, func(i, j int) bool { return g.PathLength(paths[i]) < g.PathLength(paths[j]) })
+		}
+		if count := turns(paths); count > 0 && (bestPaths == nil || count < bestTurns) {
+			bestPaths = paths
+			bestTurns = count
+		}
+	}
+	return bestPaths, bestTurns
+}
+
+// newFlowNetwork builds the node-split flow network of the graph, where each
+// tunnel costs the number of turns needed to cross it, and returns it with
+// its source and sink nodes. The source sends ants[i] units of flow to
+// starts[i], and every end room leads to the sink. The capacities of rooms
+// and tunnels follow the graph's capacity model and the rooms' own
+// capacities; an unlimited capacity is numAnts, since no more ants can use it.
+// If allowed is not nil, only the tunnels for which it returns true are used.
+func (g *Graph) newFlowNetwork(starts, ants []int, numAnts int, allowed func(from, to int) bool) (*flowNetwork, int, int) {
+	tunnelCapacity := 1
+	if !g.Capacity.LimitsTunnels() {
+		tunnelCapacity = numAnts
+	}
+
+	terminal := g.terminals()
+
+	source, sink := 2*len(g.Names), 2*len(g.Names)+1
+	network := newFlowNetwork(2*len(g.Names) + 2)
+	for i, id := range starts {
+		network.addEdge(source, 2*id+1, ants[i], 0)
+	}
+	for _, id := range g.ends() {
+		network.addEdge(2*id, sink, numAnts, 0)
+	}
+	for id, links := range g.Adj {
+		capacity := min(g.RoomCapacity(id), numAnts)
+		if terminal[id] || !g.Capacity.LimitsRooms() {
+			capacity = numAnts
+		}
+		network.addEdge(2*id, 2*id+1, capacity, 0)
+		for i, to := range links {
+			if allowed != nil && !allowed(id, to) {
+				continue
+			}
+			weight := 1
+			if g.Weights != nil {
+				weight = g.Weights[id][i]
+			}
+			network.addEdge(2*id+1, 2*to, tunnelCapacity, weight)
+		}
+	}
+	return network, source, sink
+}
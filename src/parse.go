@@ -2,13 +2,14 @@ package src
 
 import (
 	"bufio"
-	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
-// parseInputFile reads and parses the input file, creating a LemInData struct.
+// ParseInputFile reads and parses the input file, creating a LemInData struct.
 func ParseInputFile(filePath string) (*LemInData, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -16,63 +17,25 @@ func ParseInputFile(filePath string) (*LemInData, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	return ParseInput(file)
+}
+
+// maxLineSize is the longest input line the parsers accept.
+const maxLineSize = 1 << 30
+
+// ParseInput reads and parses a colony description from r, creating a LemInData struct.
+// Problems are reported as *ParseError values carrying the offending line.
+func ParseInput(r io.Reader) (*LemInData, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
 	lemInData := NewLemInData()
-	nextIsStart := false
-	nextIsEnd := false
-	hasAntsNumber := false
+	parser := &lineParser{builder: lemInData}
 
 	for scanner.Scan() {
 		line := scanner.Text()
-
-		if !hasAntsNumber {
-			// Parse the number of ants (first line of the file)
-			lemInData.NumAnts, err = strconv.Atoi(line)
-			if err != nil || lemInData.NumAnts < 1 {
-				return nil, fmt.Errorf("invalid number of ants: %s", line)
-			}
-			hasAntsNumber = true
-			continue
-		}
-
-		if line == "##start" {
-			nextIsStart = true
-		} else if line == "##end" {
-			nextIsEnd = true
-		} else if strings.Contains(line, " ") && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "L") {
-			// Room definition
-			parts := strings.Fields(line)
-			if len(parts) != 3 {
-				return nil, fmt.Errorf("invalid room definition: %s", line)
-			}
-			name := parts[0]
-			x, err := strconv.Atoi(parts[1])
-			if err != nil {
-				return nil, fmt.Errorf("invalid x coordinate: %s", parts[1])
-			}
-			y, err := strconv.Atoi(parts[2])
-			if err != nil {
-				return nil, fmt.Errorf("invalid y coordinate: %s", parts[2])
-			}
-			lemInData.AddRoom(name, x, y)
-
-			if nextIsStart {
-				lemInData.SetStartRoom(name)
-				nextIsStart = false
-			} else if nextIsEnd {
-				lemInData.SetEndRoom(name)
-				nextIsEnd = false
-			}
-		} else if strings.Contains(line, "-") && !strings.HasPrefix(line, "#") {
-			// Link definition
-			parts := strings.Split(line, "-")
-			if len(parts) != 2 {
-				return nil, fmt.Errorf("invalid link definition: %s", line)
-			}
-			if parts[0] == parts[1] {
-				return nil, fmt.Errorf("room cannot link to itself: %s", line)
-			}
-			lemInData.AddLink(parts[0], parts[1])
+		lemInData.Lines = append(lemInData.Lines, line)
+		if err := parser.parseLine(line); err != nil {
+			return nil, err
 		}
 	}
 
@@ -80,9 +43,329 @@ func ParseInputFile(filePath string) (*LemInData, error) {
 		return nil, err
 	}
 
-	if lemInData.StartRoom == "" || lemInData.EndRoom == "" {
-		return nil, fmt.Errorf("start or end room not defined")
+	shares, err := parser.finish()
+	if err != nil {
+		return nil, err
+	}
+	lemInData.NumAnts = parser.numAnts
+	if shares != nil {
+		lemInData.StartAnts = make(map[string]int, len(shares))
+		for i, start := range lemInData.StartRooms {
+			lemInData.StartAnts[start] = shares[i]
+		}
 	}
 
 	return lemInData, nil
 }
+
+// colonyBuilder stores the rooms and links read by a lineParser.
+type colonyBuilder interface {
+	// hasRoom reports whether a room of that name was added.
+	hasRoom(name string) bool
+	// addRoom adds a room, which is a start or an end room if start or end
+	// is set. A capacity of 0 means the default capacity.
+	addRoom(name string, x, y, capacity int, start, end bool)
+	// addLink adds a link between two known rooms, read on line lineNumber.
+	addLink(link linkDef, lineNumber int) error
+}
+
+// lineParser reads a colony description line by line, validating it and
+// passing its rooms and links to builder. ParseInput and ParseStream share
+// it and differ only in how they read lines and store the colony.
+type lineParser struct {
+	builder    colonyBuilder
+	numAnts    int
+	lineNumber int
+
+	hasAntsNumber, hasStart, hasEnd bool
+
+	// pending is the ##start or ##end command waiting for its room line
+	pending     string
+	pendingLine int
+
+	// pendingCapacity is the capacity set by a ##capacity command for the next room
+	pendingCapacity int
+	capacityLine    int
+	capacityText    string
+
+	// pendingAnts is the number of ants set by a ##ants command for the next
+	// start room, and startAnts the numbers set for each start room so far
+	pendingAnts int
+	antsLine    int
+	antsText    string
+	startAnts   []int
+
+	// directed is set by a ##directed command: the "a-b" links after it
+	// are one-way, like "a>b"
+	directed bool
+}
+
+// parseLine handles the next line of the description.
+func (p *lineParser) parseLine(line string) error {
+	p.lineNumber++
+	fail := func(kind error, column int) error {
+		return &ParseError{Line: p.lineNumber, Column: column, Kind: kind, Text: line}
+	}
+
+	if !p.hasAntsNumber {
+		// Parse the number of ants (first line of the file)
+		numAnts, err := strconv.Atoi(line)
+		if err != nil || numAnts < 1 {
+			return fail(ErrInvalidAnts, 0)
+		}
+		p.numAnts = numAnts
+		p.hasAntsNumber = true
+		return nil
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	if line == "##start" || line == "##end" {
+		if p.pending != "" {
+			return fail(ErrCommandNoRoom, 0)
+		}
+		p.pending, p.pendingLine = line, p.lineNumber
+	} else if strings.HasPrefix(line, "##capacity") {
+		if p.pendingCapacity != 0 {
+			return fail(ErrCommandNoRoom, 0)
+		}
+		capacity, perr := parseCommandNumber(line, ErrInvalidCapacity)
+		if perr != nil {
+			return fail(perr.Kind, perr.Column)
+		}
+		p.pendingCapacity, p.capacityLine, p.capacityText = capacity, p.lineNumber, line
+	} else if strings.HasPrefix(line, "##ants") {
+		if p.pendingAnts != 0 {
+			return fail(ErrCommandNoRoom, 0)
+		}
+		ants, perr := parseCommandNumber(line, ErrInvalidAnts)
+		if perr != nil {
+			return fail(perr.Kind, perr.Column)
+		}
+		p.pendingAnts, p.antsLine, p.antsText = ants, p.lineNumber, line
+	} else if line == "##directed" {
+		p.directed = true
+	} else if strings.HasPrefix(line, "#") {
+		// Comment or unknown command: ignored
+		return nil
+	} else if strings.Contains(line, " ") && !isWeightedLink(line) {
+		// Room definition
+		name, x, y, capacity, perr := parseRoom(line)
+		if perr != nil {
+			return fail(perr.Kind, perr.Column)
+		}
+		if p.pendingAnts != 0 && p.pending != "##start" {
+			return fail(ErrAntsNoStart, 0)
+		}
+		if p.builder.hasRoom(name) {
+			return fail(ErrDuplicateRoom, 1)
+		}
+		if capacity == 0 {
+			capacity = p.pendingCapacity
+		}
+		p.builder.addRoom(name, x, y, capacity, p.pending == "##start", p.pending == "##end")
+		p.pendingCapacity = 0
+
+		if p.pending == "##start" {
+			p.hasStart = true
+			p.startAnts = append(p.startAnts, p.pendingAnts)
+		} else if p.pending == "##end" {
+			p.hasEnd = true
+		}
+		p.pending = ""
+		p.pendingAnts = 0
+	} else if strings.ContainsAny(line, "->") {
+		// Link definition
+		if p.pending != "" || p.pendingCapacity != 0 || p.pendingAnts != 0 {
+			return fail(ErrCommandNoRoom, 0)
+		}
+		link, perr := parseLink(line)
+		if perr != nil {
+			return fail(perr.Kind, perr.Column)
+		}
+		if !p.builder.hasRoom(link.from) {
+			return fail(ErrUnknownRoom, 1)
+		}
+		if !p.builder.hasRoom(link.to) {
+			return fail(ErrUnknownRoom, len(link.from)+2)
+		}
+		link.directed = link.directed || p.directed
+		if err := p.builder.addLink(link, p.lineNumber); err != nil {
+			return fail(err, 1)
+		}
+	} else {
+		return fail(ErrInvalidLine, 0)
+	}
+	return nil
+}
+
+// finish checks the description once every line is read. It returns the
+// number of ants leaving from each start room, in input order, or nil when
+// they all leave from the first one.
+func (p *lineParser) finish() ([]int, error) {
+	if !p.hasAntsNumber {
+		return nil, &ParseError{Kind: ErrInvalidAnts}
+	}
+	if p.pending != "" {
+		return nil, &ParseError{Line: p.pendingLine, Kind: ErrCommandNoRoom, Text: p.pending}
+	}
+	if p.pendingCapacity != 0 {
+		return nil, &ParseError{Line: p.capacityLine, Kind: ErrCommandNoRoom, Text: p.capacityText}
+	}
+	if p.pendingAnts != 0 {
+		return nil, &ParseError{Line: p.antsLine, Kind: ErrCommandNoRoom, Text: p.antsText}
+	}
+	if !p.hasStart {
+		return nil, &ParseError{Kind: ErrNoStart}
+	}
+	if !p.hasEnd {
+		return nil, &ParseError{Kind: ErrNoEnd}
+	}
+
+	if len(p.startAnts) > 1 || p.startAnts[0] != 0 {
+		shares, err := shareAnts(p.numAnts, p.startAnts)
+		if err != nil {
+			return nil, &ParseError{Kind: err}
+		}
+		return shares, nil
+	}
+	return nil, nil
+}
+
+// parseRoom splits a "name x y" room definition, optionally followed by the
+// room's capacity, into its fields. The capacity is 0 when not given. On
+// failure it returns a *ParseError holding the kind and column of the problem.
+func parseRoom(line string) (string, int, int, int, *ParseError) {
+	parts := strings.Fields(line)
+	columns := fieldColumns(line)
+	if len(parts) != 3 && len(parts) != 4 {
+		return "", 0, 0, 0, &ParseError{Kind: ErrInvalidRoom}
+	}
+	name := parts[0]
+	if strings.HasPrefix(name, "L") || strings.HasPrefix(name, "#") || strings.ContainsAny(name, "->") {
+		return "", 0, 0, 0, &ParseError{Kind: ErrInvalidRoomName, Column: columns[0]}
+	}
+	x, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, 0, 0, &ParseError{Kind: ErrInvalidCoordinate, Column: columns[1]}
+	}
+	y, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", 0, 0, 0, &ParseError{Kind: ErrInvalidCoordinate, Column: columns[2]}
+	}
+	capacity := 0
+	if len(parts) == 4 {
+		capacity, err = strconv.Atoi(parts[3])
+		if err != nil || capacity < 1 {
+			return "", 0, 0, 0, &ParseError{Kind: ErrInvalidCapacity, Column: columns[3]}
+		}
+	}
+	return name, x, y, capacity, nil
+}
+
+// parseCommandNumber reads the positive number of a "##capacity N" or
+// "##ants N" command, reporting kind if it is missing or invalid.
+func parseCommandNumber(line string, kind error) (int, *ParseError) {
+	parts := strings.Fields(line)
+	if len(parts) != 2 {
+		return 0, &ParseError{Kind: kind}
+	}
+	number, err := strconv.Atoi(parts[1])
+	if err != nil || number < 1 {
+		return 0, &ParseError{Kind: kind, Column: fieldColumns(line)[1]}
+	}
+	return number, nil
+}
+
+// shareAnts returns the number of ants leaving from each start room. given
+// holds the numbers set with ##ants, 0 for the start rooms without one,
+// which share the remaining ants evenly, the first ones getting one more.
+func shareAnts(numAnts int, given []int) ([]int, error) {
+	shares := make([]int, len(given))
+	remaining, unset := numAnts, 0
+	for i, ants := range given {
+		shares[i] = ants
+		remaining -= ants
+		if ants == 0 {
+			unset++
+		}
+	}
+	if remaining < 0 || (unset == 0 && remaining > 0) {
+		return nil, ErrAntsMismatch
+	}
+	rank := 0
+	for i := range shares {
+		if given[i] == 0 {
+			shares[i] = remaining / unset
+			if rank < remaining%unset {
+				shares[i]++
+			}
+			rank++
+		}
+	}
+	return shares, nil
+}
+
+// linkDef is a link definition read from the input.
+type linkDef struct {
+	from, to string
+	weight   int  // Turns needed to cross the tunnel
+	directed bool // The tunnel can only be crossed from from to to
+}
+
+// parseLink splits a "room1-room2" link definition, or "room1>room2" for a
+// one-way tunnel, optionally followed by the number of turns needed to cross
+// the tunnel (1 when not given). On failure it returns a *ParseError holding
+// the kind of the problem.
+func parseLink(line string) (linkDef, *ParseError) {
+	text, link := line, linkDef{weight: 1}
+	if isWeightedLink(line) {
+		fields := strings.Fields(line)
+		weight, err := strconv.Atoi(fields[1])
+		if err != nil || weight < 1 {
+			return linkDef{}, &ParseError{Kind: ErrInvalidWeight, Column: fieldColumns(line)[1]}
+		}
+		text, link.weight = fields[0], weight
+	}
+	separator := "-"
+	if strings.Contains(text, ">") {
+		separator, link.directed = ">", true
+	}
+	from, to, _ := strings.Cut(text, separator)
+	if strings.Count(text, "-")+strings.Count(text, ">") != 1 || from == "" || to == "" {
+		return linkDef{}, &ParseError{Kind: ErrInvalidLink}
+	}
+	if from == to {
+		return linkDef{}, &ParseError{Kind: ErrSelfLink}
+	}
+	link.from, link.to = from, to
+	return link, nil
+}
+
+// isWeightedLink reports whether line is a "room1-room2 weight" or
+// "room1>room2 weight" link definition. Room names cannot contain '-' or
+// '>', so it cannot be a room.
+func isWeightedLink(line string) bool {
+	if strings.IndexFunc(line, unicode.IsSpace) < 0 {
+		return false
+	}
+	fields := strings.Fields(line)
+	return len(fields) == 2 && strings.ContainsAny(fields[0], "->")
+}
+
+// fieldColumns returns the 1-based column where each whitespace-separated
+// field of line starts.
+func fieldColumns(line string) []int {
+	var columns []int
+	inField := false
+	for i, r := range line {
+		isSpace := unicode.IsSpace(r)
+		if !isSpace && !inField {
+			columns = append(columns, i+1)
+		}
+		inField = !isSpace
+	}
+	return columns
+}
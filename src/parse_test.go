@@ -0,0 +1,119 @@
+package src
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseInputErrors(t *testing.T) {
+	const rooms = "2\n##start\ns 0 0\na 1 0\n##end\ne 2 0\n"
+	tests := []struct {
+		name  string
+		input string
+		kind  error
+		line  int
+	}{
+		{"no ants", "", ErrInvalidAnts, 0},
+		{"invalid ants", "zero\n", ErrInvalidAnts, 1},
+		{"no start", "2\na 0 0\n##end\ne 1 0\na-e\n", ErrNoStart, 0},
+		{"no end", "2\n##start\ns 0 0\na 1 0\ns-a\n", ErrNoEnd, 0},
+		{"command without room", rooms + "##start\n", ErrCommandNoRoom, 7},
+		{"invalid room", rooms + "b 1\n", ErrInvalidRoom, 7},
+		{"invalid room name", rooms + "Lb 1 1\n", ErrInvalidRoomName, 7},
+		{"invalid coordinate", rooms + "b x 1\n", ErrInvalidCoordinate, 7},
+		{"invalid capacity", rooms + "b 1 1 0\n", ErrInvalidCapacity, 7},
+		{"duplicate room", rooms + "a 3 3\n", ErrDuplicateRoom, 7},
+		{"invalid link", rooms + "s-a-e\n", ErrInvalidLink, 7},
+		{"invalid weight", rooms + "s-a 0\n", ErrInvalidWeight, 7},
+		{"self link", rooms + "a-a\n", ErrSelfLink, 7},
+		{"unknown room", rooms + "a-z\n", ErrUnknownRoom, 7},
+		{"duplicate link", rooms + "s-a\na-s\n", ErrDuplicateLink, 8},
+		{"duplicate one-way link", rooms + "s>a\na-s\n", ErrDuplicateLink, 8},
+		{"duplicate weighted link", rooms + "s-a\na-s 2\n", ErrDuplicateLink, 8},
+		{"duplicate directed link", rooms + "##directed\ns-a\na>s\ns-a 3\n", ErrDuplicateLink, 10},
+		{"ants without start", rooms + "##ants 1\nb 1 1\n", ErrAntsNoStart, 8},
+		{"ants mismatch", "2\n##start\n##ants 3\ns 0 0\n##end\ne 1 0\ns-e\n", ErrAntsMismatch, 0},
+		{"invalid line", rooms + "s_a\n", ErrInvalidLine, 7},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseInput(strings.NewReader(test.input))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, test.kind) {
+				t.Fatalf("ParseInput error = %v; want a *ParseError of kind %v", err, test.kind)
+			}
+			if parseErr.Line != test.line {
+				t.Errorf("ParseInput error on line %d; want line %d", parseErr.Line, test.line)
+			}
+
+			// ParseStream shares the line handling and must report the same
+			// error, with the line as written
+			_, _, streamErr := ParseStream(strings.NewReader(test.input))
+			if streamErr == nil || streamErr.Error() != err.Error() {
+				t.Errorf("ParseStream error = %v; want %v", streamErr, err)
+			}
+		})
+	}
+}
+
+func TestParseInputRoomCapacities(t *testing.T) {
+	const input = `1
+##start
+s 0 0
+##capacity 3
+a 1 0
+b 1 1 2
+##capacity 3
+c 2 0 4
+d 2 1
+##end
+e 3 0
+s-a
+`
+	l, err := ParseInput(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	colony, _, err := ParseStream(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fourth field of a room line takes precedence over ##capacity
+	for name, want := range map[string]int{"a": 3, "b": 2, "c": 4, "d": 1} {
+		if got := l.Rooms[name].Capacity; got != want {
+			t.Errorf("ParseInput capacity of %s = %d; want %d", name, got, want)
+		}
+		if got := colony.Capacities[colony.IDs[name]]; got != want {
+			t.Errorf("ParseStream capacity of %s = %d; want %d", name, got, want)
+		}
+	}
+}
+
+func TestParseInputLinkWeights(t *testing.T) {
+	const rooms = "1\n##start\ns 0 0\n##end\na 1 0\n"
+	tests := []struct {
+		name       string
+		links      string
+		sToA, aToS int
+	}{
+		{"two-way", "s-a 3\n", 3, 3},
+		{"one-way", "s>a 3\n", 3, 1},
+		{"one-way back without weight", "s>a 3\na>s\n", 3, 1},
+		{"one-way back with its own weight", "s>a 3\na>s 2\n", 3, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, err := ParseInput(strings.NewReader(rooms + test.links))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.LinkWeight("s", "a"); got != test.sToA {
+				t.Errorf("LinkWeight(s, a) = %d; want %d", got, test.sToA)
+			}
+			if got := l.LinkWeight("a", "s"); got != test.aToS {
+				t.Errorf("LinkWeight(a, s) = %d; want %d", got, test.aToS)
+			}
+		})
+	}
+}
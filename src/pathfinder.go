@@ -4,31 +4,42 @@ import (
 	"container/list"
 )
 
+// FindAllPathsBFS enumerates every simple path from start to end. The number
+// of paths grows exponentially with the size of the map: prefer
+// FindOptimalPaths except on small maps.
 func FindAllPathsBFS(rooms map[string]*Room, start, end string) [][]string {
-	var paths [][]string
+	g := NewGraph(&LemInData{Rooms: rooms, StartRoom: start, EndRoom: end})
+	if g.Start < 0 || g.End < 0 {
+		return nil
+	}
+	return g.PathsNames(g.AllPaths())
+}
+
+// AllPaths enumerates every simple path from a start room to an end room.
+func (g *Graph) AllPaths() [][]int {
+	var paths [][]int
 	queue := list.New()
-	queue.PushBack([]string{start})
+	starts, _ := g.starts(0)
+	for _, start := range starts {
+		queue.PushBack([]int{start})
+	}
+	isEnd := make([]bool, len(g.Names))
+	for _, end := range g.ends() {
+		isEnd[end] = true
+	}
 
 	for queue.Len() > 0 {
-		// Print the current state of the queue
-		// fmt.Println("Current queue:")
-		// for e := queue.Front(); e != nil; e = e.Next() {
-		// 	fmt.Printf("%v ", e.Value)
-		// }
-		// fmt.Println()
-
-		path := queue.Remove(queue.Front()).([]string)
+		path := queue.Remove(queue.Front()).([]int)
 		lastRoom := path[len(path)-1]
-		// fmt.Println("Processing room:", lastRoom)
 
-		if lastRoom == end {
+		if isEnd[lastRoom] {
 			paths = append(paths, path)
 			continue
 		}
 		// Explore other rooms as before
-		for _, nextRoom := range rooms[lastRoom].Links {
-			if !Contains(path, nextRoom) {
-				newPath := make([]string, len(path))
+		for _, nextRoom := range g.Adj[lastRoom] {
+			if !containsID(path, nextRoom) {
+				newPath := make([]int, len(path), len(path)+1)
 				copy(newPath, path)
 				newPath = append(newPath, nextRoom)
 				queue.PushBack(newPath)
@@ -37,6 +48,7 @@ func FindAllPathsBFS(rooms map[string]*Room, start, end string) [][]string {
 	}
 	return paths
 }
+
 func Contains(slice []string, item string) bool {
 	for _, v := range slice {
 		if v == item {
@@ -46,48 +58,106 @@ func Contains(slice []string, item string) bool {
 	return false
 }
 
-// SelectOptimalPaths chooses the best paths for ant movement.
-func FilterPath(AllPaths [][]string, start string, end string) [][]string {
-	BestSolution := [][]string{}
+func containsID(slice []int, item int) bool {
+	for _, v := range slice {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterPath chooses the combination of disjoint paths that moves numAnts
+// ants in the fewest turns, and returns it with that turn count.
+func FilterPath(AllPaths [][]string, start string, end string, numAnts int) ([][]string, int) {
+	paths, g := pathGraph(AllPaths, start, end)
+	best, turns := g.FilterPaths(paths, numAnts)
+	return g.PathsNames(best), turns
+}
+
+// FilterPaths chooses the combination of paths among allPaths that moves
+// numAnts ants in the fewest turns, and returns it with that turn count.
+// Combined paths share no tunnel and pass through each intermediate room at
+// most its capacity of times; the graph's capacity model can lift either rule.
+func (g *Graph) FilterPaths(allPaths [][]int, numAnts int) ([][]int, int) {
+	BestSolution := [][]int{}
+	BestTurns := 0
+	used := make([]int, len(g.Names))
+	terminal := g.terminals()
 
 	// Parcourir tous les chemins comme point de départ potentiel
-	for i := 0; i < len(AllPaths); i++ {
-		CurrentSolution := [][]string{AllPaths[i]} // Commence avec le premier chemin
+	for i := 0; i < len(allPaths); i++ {
+		for id := range used {
+			used[id] = 0
+		}
+		usedLinks := make(map[[2]int]bool)
+		CurrentSolution := [][]int{allPaths[i]} // Commence avec le premier chemin
+		CurrentTurns := g.CountTurns(CurrentSolution, numAnts)
+		g.markPath(used, usedLinks, terminal, allPaths[i])
 
-		// Essayer de combiner ce chemin avec d'autres
-		for j := 0; j < len(AllPaths); j++ {
-			if i != j && CheckPath(CurrentSolution, AllPaths[j], start, end) {
-				CurrentSolution = append(CurrentSolution, AllPaths[j])
+		// Essayer de combiner ce chemin avec d'autres, en gardant chaque
+		// combinaison intermédiaire comme candidate
+		for j := 0; j < len(allPaths); j++ {
+			if i != j && !g.conflicts(used, usedLinks, terminal, allPaths[j]) {
+				Candidate := append(CurrentSolution[:len(CurrentSolution):len(CurrentSolution)], allPaths[j])
+				// A turn count of 0 means some start room has no path yet
+				if turns := g.CountTurns(Candidate, numAnts); CurrentTurns == 0 || (turns > 0 && turns <= CurrentTurns) {
+					CurrentSolution = Candidate
+					CurrentTurns = turns
+					g.markPath(used, usedLinks, terminal, allPaths[j])
+				}
 			}
 		}
 
-		// Mettre à jour la meilleure solution si la solution courante est meilleure
-		if len(CurrentSolution) > len(BestSolution) {
+		// Mettre à jour la meilleure solution si elle demande moins de tours ;
+		// une solution à 0 tour laisse des fourmis sans chemin
+		if CurrentTurns > 0 && (BestTurns == 0 || CurrentTurns < BestTurns) {
 			BestSolution = CurrentSolution
+			BestTurns = CurrentTurns
 		}
 	}
 
-	return BestSolution
+	return BestSolution, BestTurns
+}
+
+// markPath counts the intermediate rooms of path in used, except the start
+// and end rooms flagged in terminal, and marks its tunnels in usedLinks.
+func (g *Graph) markPath(used []int, usedLinks map[[2]int]bool, terminal []bool, path []int) {
+	for i, room := range path {
+		if i > 0 && i < len(path)-1 && !terminal[room] {
+			used[room]++
+		}
+		if i > 0 {
+			usedLinks[[2]int{min(path[i-1], room), max(path[i-1], room)}] = true
+		}
+	}
+}
+
+// conflicts reports whether path goes through a room already used by as many
+// paths as its capacity, or a tunnel marked in usedLinks, when the capacity
+// model limits them.
+func (g *Graph) conflicts(used []int, usedLinks map[[2]int]bool, terminal []bool, path []int) bool {
+	for i, room := range path {
+		if g.Capacity.LimitsRooms() && !terminal[room] && used[room] >= g.RoomCapacity(room) {
+			return true
+		}
+		if i > 0 && g.Capacity.LimitsTunnels() && usedLinks[[2]int{min(path[i-1], room), max(path[i-1], room)}] {
+			return true
+		}
+	}
+	return false
 }
 
 // CheckPath vérifie si le chemin "current" peut être ajouté à la solution courante "path"
 // sans partager de pièces autres que start et end
 func CheckPath(path [][]string, current []string, start string, end string) bool {
-	// Vérifier chaque chemin déjà dans la solution
-	for i := 0; i < len(path); i++ {
-		for _, room := range path[i] {
-			// Ignorer les pièces de départ et d'arrivée
-			if room == start || room == end {
-				continue
-			}
-
-			// Si une pièce du chemin actuel existe déjà dans le chemin en cours, on retourne false
-			for _, curRoom := range current {
-				if curRoom == room && curRoom != start && curRoom != end {
-					return false
-				}
-			}
-		}
+	paths, g := pathGraph(append(path[:len(path):len(path)], current), start, end)
+	g.Capacity = VertexCapacity
+	used := make([]int, len(g.Names))
+	usedLinks := make(map[[2]int]bool)
+	terminal := g.terminals()
+	for _, p := range paths[:len(path)] {
+		g.markPath(used, usedLinks, terminal, p)
 	}
-	return true
+	return !g.conflicts(used, usedLinks, terminal, paths[len(path)])
 }
@@ -0,0 +1,213 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Simulate moves the ants along their assigned paths and returns the moves
+// made during each turn. See Graph.Simulate for the rules it enforces.
+func Simulate(paths [][]string, antDistribution [][]int) []Turn {
+	idPaths, g := pathGraph(paths, "", "")
+	return g.Simulate(idPaths, antDistribution)
+}
+
+// Simulate moves the ants along their assigned paths of room IDs and returns
+// the moves made during each turn. It is the single source of truth for the
+// schedule: printing and visualization are layered on top of its result.
+//
+// The lem-in rules are enforced on every turn: an intermediate room holds at
+// most its capacity of ants, one unless set otherwise (the start and end
+// rooms hold any number), each tunnel is entered at most once, and an ant
+// moves at most once. The graph's capacity model can lift the room or the
+// tunnel rule. Ants closest to an end room move first so that the rooms they
+// leave can be entered in the same turn.
+//
+// An ant crossing a tunnel that takes several turns leaves its room when it
+// enters the tunnel and stays in transit until it reaches the next room; its
+// move is reported on the turn it arrives. It only enters the tunnel when the
+// next room has space for it, and keeps that space while in transit, so that
+// it always arrives on time, as Verify expects. Turns where every moving ant
+// is in transit are returned empty.
+func (g *Graph) Simulate(paths [][]int, antDistribution [][]int) []Turn {
+	type AntPosition struct {
+		ant     int
+		path    int
+		step    int
+		arrival int // Turn the ant reaches step+1 when in transit, 0 when in a room
+	}
+
+	// Ants still in the start room wait in a queue per path, so that each
+	// turn only looks at the ants inside the colony and the head of each queue
+	waiting := make([][]int, len(antDistribution))
+	remaining := 0
+	for pathIndex, ants := range antDistribution {
+		waiting[pathIndex] = ants
+		remaining += len(ants)
+	}
+	var antPositions []AntPosition
+
+	// occupants counts the ants in each intermediate room, or on their way to
+	// it through a long tunnel. The start and end rooms are unlimited, also
+	// when a path goes through another start room
+	occupants := make([]int, len(g.Names))
+	terminal := g.terminals()
+	isEndpoint := func(pos AntPosition, step int) bool {
+		return step == 0 || step == len(paths[pos.path])-1 || terminal[paths[pos.path][step]]
+	}
+
+	// toEnd holds, for each step of each path, the turns left to reach its
+	// end room. It orders ants of different paths, whose step indexes cannot
+	// be compared
+	toEnd := make([][]int, len(paths))
+	for pathIndex, path := range paths {
+		toEnd[pathIndex] = make([]int, len(path))
+		for step := len(path) - 2; step >= 0; step-- {
+			toEnd[pathIndex][step] = toEnd[pathIndex][step+1] + g.Weight(path[step], path[step+1])
+		}
+	}
+	distance := func(pos AntPosition, turn int) int {
+		if pos.arrival > 0 {
+			return max(pos.arrival-turn, 0) + toEnd[pos.path][pos.step+1]
+		}
+		return toEnd[pos.path][pos.step]
+	}
+
+	var turns []Turn
+	for remaining > 0 {
+		turn := len(turns) + 1
+
+		// Let the ants closest to an end room move first, so that the rooms
+		// they leave can be entered in the same turn, the ants leaving a
+		// start room coming in order after the others at the same distance
+		var heads []AntPosition
+		for pathIndex, ants := range waiting {
+			if len(ants) > 0 {
+				heads = append(heads, AntPosition{ants[0], pathIndex, 0, 0})
+			}
+		}
+		sort.Slice(heads, func(i, j int) bool { return heads[i].ant < heads[j].ant })
+		movers := append(antPositions, heads...)
+		sort.SliceStable(movers, func(i, j int) bool { return distance(movers[i], turn) < distance(movers[j], turn) })
+
+		moves := Turn{}
+		var newPositions []AntPosition
+		usedLinks := make(map[[2]int]bool)
+		inTransit := false
+		arrive := func(pos AntPosition, currentRoom, nextRoom int) {
+			if pos.arrival == 0 && !isEndpoint(pos, pos.step+1) {
+				occupants[nextRoom]++
+			}
+			moves = append(moves, Move{Ant: pos.ant, From: g.Names[currentRoom], To: g.Names[nextRoom]})
+			if pos.step+1 < len(paths[pos.path])-1 {
+				newPositions = append(newPositions, AntPosition{pos.ant, pos.path, pos.step + 1, 0})
+			} else {
+				remaining--
+			}
+		}
+		for _, pos := range movers {
+			currentRoom := paths[pos.path][pos.step]
+			nextRoom := paths[pos.path][pos.step+1]
+			roomBusy := g.Capacity.LimitsRooms() && occupants[nextRoom] >= g.RoomCapacity(nextRoom) && !isEndpoint(pos, pos.step+1)
+
+			if pos.arrival > 0 {
+				// The ant is in transit: it enters the next room, where its
+				// space is kept, once the tunnel is crossed
+				if pos.arrival > turn {
+					inTransit = true
+					newPositions = append(newPositions, pos)
+					continue
+				}
+				arrive(pos, currentRoom, nextRoom)
+				continue
+			}
+
+			link := [2]int{min(currentRoom, nextRoom), max(currentRoom, nextRoom)}
+			weight := g.Weight(currentRoom, nextRoom)
+			tunnelBusy := g.Capacity.LimitsTunnels() && usedLinks[link]
+			if tunnelBusy || roomBusy {
+				if pos.step > 0 {
+					newPositions = append(newPositions, pos)
+				}
+				continue
+			}
+
+			usedLinks[link] = true
+			if pos.step == 0 {
+				waiting[pos.path] = waiting[pos.path][1:]
+			} else if !isEndpoint(pos, pos.step) {
+				occupants[currentRoom]--
+			}
+			if weight > 1 {
+				if !isEndpoint(pos, pos.step+1) {
+					occupants[nextRoom]++
+				}
+				newPositions = append(newPositions, AntPosition{pos.ant, pos.path, pos.step, turn + weight - 1})
+				inTransit = true
+				continue
+			}
+			arrive(pos, currentRoom, nextRoom)
+		}
+		if len(moves) == 0 && !inTransit {
+			// No ant can move any more: the paths are not usable together
+			break
+		}
+		sort.Slice(moves, func(i, j int) bool { return moves[i].Ant < moves[j].Ant })
+		turns = append(turns, moves)
+		antPositions = newPositions
+	}
+	return turns
+}
+
+// linkKey identifies the tunnel between two rooms regardless of direction.
+func linkKey(room1, room2 string) string {
+	if room1 > room2 {
+		room1, room2 = room2, room1
+	}
+	return room1 + "-" + room2
+}
+
+// String formats the move as "Lx-room".
+func (m Move) String() string {
+	return fmt.Sprintf("L%d-%s", m.Ant, m.To)
+}
+
+// String formats the turn as its moves separated by spaces.
+func (t Turn) String() string {
+	moves := make([]string, len(t))
+	for i, move := range t {
+		moves[i] = move.String()
+	}
+	return strings.Join(moves, " ")
+}
+
+// WriteTurns writes one line of "Lx-room" moves per turn to w.
+func WriteTurns(w io.Writer, turns []Turn) error {
+	bw := bufio.NewWriter(w)
+	for _, turn := range turns {
+		if _, err := fmt.Fprintln(bw, turn); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteInput writes the input lines to w exactly as they were read.
+func (l *LemInData) WriteInput(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range l.Lines {
+		if _, err := fmt.Fprintln(bw, line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// SimulateAntMovement simulates and prints the movement of ants through the colony.
+func SimulateAntMovement(paths [][]string, antDistribution [][]int) {
+	WriteTurns(os.Stdout, Simulate(paths, antDistribution))
+}
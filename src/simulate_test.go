@@ -0,0 +1,46 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths [][]string
+		ants  [][]int
+		want  string // One line per turn
+	}{
+		{
+			"room holds one ant",
+			[][]string{{"s", "a", "e"}, {"s", "a", "e"}},
+			[][]int{{1, 3}, {2}},
+			"L1-a\nL1-e L2-a\nL2-e L3-a\nL3-e",
+		},
+		{
+			"paths merging into a room",
+			[][]string{{"s", "a", "b", "e"}, {"s", "c", "b", "e"}},
+			[][]int{{1, 3}, {2, 4}},
+			"L1-a L2-c\nL1-b L3-a\nL1-e L2-b L4-c\nL2-e L3-b\nL3-e L4-b\nL4-e",
+		},
+		{
+			"tunnel used once per turn",
+			[][]string{{"s", "e"}},
+			[][]int{{1, 2, 3}},
+			"L1-e\nL2-e\nL3-e",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			turns := Simulate(test.paths, test.ants)
+			lines := make([]string, len(turns))
+			for i, turn := range turns {
+				lines[i] = turn.String()
+			}
+			if got := strings.Join(lines, "\n"); got != test.want {
+				t.Errorf("Simulate =\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
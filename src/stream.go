@@ -0,0 +1,280 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// CompactColony is a colony stored for very large inputs: room names are
+// interned to dense integer IDs and links are kept in compressed sparse row
+// (CSR) form instead of a []string per room.
+type CompactColony struct {
+	NumAnts    int              // Total number of ants
+	Names      []string         // Room names, indexed by room ID
+	IDs        map[string]int32 // Room IDs, keyed by room name
+	X, Y       []int            // Coordinates of the rooms, indexed by room ID
+	Capacities []int            // Capacities of the rooms, indexed by room ID
+	Start      int32            // ID of the start room (the first one when there are several)
+	End        int32            // ID of the end room (the first one when there are several)
+	Starts     []int32          // IDs of the start rooms, in input order
+	Ends       []int32          // IDs of the end rooms, in input order
+	StartAnts  []int            // Ants leaving from each room of Starts, nil when they all leave from Start
+	Offsets    []int32          // Links of room i are Targets[Offsets[i]:Offsets[i+1]]
+	Targets    []int32          // IDs of the rooms reachable from each room, sorted for each room
+	Weights    []int32          // Turns needed to cross each link of Targets, nil if all take one turn
+
+	links int // Number of links defined, one-way or not
+}
+
+// Neighbors returns the IDs of the rooms reachable from room id.
+func (c *CompactColony) Neighbors(id int32) []int32 {
+	return c.Targets[c.Offsets[id]:c.Offsets[id+1]]
+}
+
+// NumLinks returns the number of links defined in the colony.
+func (c *CompactColony) NumLinks() int {
+	return c.links
+}
+
+// ParseStats holds measurements taken while streaming a colony file.
+type ParseStats struct {
+	Bytes      int64         // Bytes read from the input
+	Lines      int           // Lines read from the input
+	Rooms      int           // Rooms defined
+	Links      int           // Links defined
+	Duration   time.Duration // Time spent parsing and building the CSR structure
+	TotalAlloc uint64        // Bytes allocated on the heap while parsing
+	HeapInUse  uint64        // Bytes of heap in use once parsing is done
+}
+
+// ParseStreamFile opens filePath and parses it with ParseStream.
+func ParseStreamFile(filePath string) (*CompactColony, *ParseStats, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	return ParseStream(file)
+}
+
+// ParseStream parses a colony description from r without keeping its lines
+// in memory and without limiting their length. It applies the same
+// validation as ParseInput and reports problems as *ParseError values.
+func ParseStream(r io.Reader) (*CompactColony, *ParseStats, error) {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	started := time.Now()
+
+	builder := &compactBuilder{
+		colony: &CompactColony{IDs: make(map[string]int32), Start: -1, End: -1},
+		pairs:  make(map[uint64]uint8),
+	}
+	parser := &lineParser{builder: builder}
+	stats := &ParseStats{}
+	reader := bufio.NewReaderSize(r, 1<<20)
+
+	var buffer []byte
+	for {
+		raw, readErr := readLine(reader, &buffer)
+		if readErr != nil && readErr != io.EOF {
+			return nil, nil, readErr
+		}
+		if readErr == io.EOF && raw == nil {
+			break
+		}
+		stats.Bytes += int64(len(raw))
+		raw = bytes.TrimSuffix(bytes.TrimSuffix(raw, []byte("\n")), []byte("\r"))
+		stats.Lines++
+		if err := parser.parseLine(string(raw)); err != nil {
+			return nil, nil, err
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	shares, err := parser.finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	colony := builder.colony
+	colony.NumAnts = parser.numAnts
+	colony.StartAnts = shares
+	builder.buildCSR()
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	stats.Rooms = len(colony.Names)
+	stats.Links = colony.NumLinks()
+	stats.Duration = time.Since(started)
+	stats.TotalAlloc = after.TotalAlloc - before.TotalAlloc
+	stats.HeapInUse = after.HeapInuse
+
+	return colony, stats, nil
+}
+
+// compactBuilder stores the rooms read by lineParser in a CompactColony, and
+// collects its links as pairs of room IDs until buildCSR sorts them into CSR
+// form.
+type compactBuilder struct {
+	colony   *CompactColony
+	from, to []int32
+	weights  []int32 // Weight of each link, nil while they all take one turn
+	oneWay   []bool  // Whether each link is one-way, nil while none is
+
+	// pairs holds, for each pair of linked rooms, which directions are
+	// linked: bit 0 from the lower ID to the higher, bit 1 back
+	pairs map[uint64]uint8
+}
+
+func (b *compactBuilder) hasRoom(name string) bool {
+	_, exists := b.colony.IDs[name]
+	return exists
+}
+
+func (b *compactBuilder) addRoom(name string, x, y, capacity int, start, end bool) {
+	c := b.colony
+	id := int32(len(c.Names))
+	c.IDs[name] = id
+	c.Names = append(c.Names, name)
+	c.X = append(c.X, x)
+	c.Y = append(c.Y, y)
+	c.Capacities = append(c.Capacities, max(capacity, 1))
+	if start {
+		if c.Start < 0 {
+			c.Start = id
+		}
+		c.Starts = append(c.Starts, id)
+	} else if end {
+		if c.End < 0 {
+			c.End = id
+		}
+		c.Ends = append(c.Ends, id)
+	}
+}
+
+// addLink records a link, or returns ErrDuplicateLink when one of its
+// directions is already linked.
+func (b *compactBuilder) addLink(link linkDef, lineNumber int) error {
+	from, to := b.colony.IDs[link.from], b.colony.IDs[link.to]
+	key := uint64(min(from, to))<<32 | uint64(max(from, to))
+	directions := uint8(3)
+	if link.directed && from < to {
+		directions = 1
+	} else if link.directed {
+		directions = 2
+	}
+	if b.pairs[key]&directions != 0 {
+		return ErrDuplicateLink
+	}
+	b.pairs[key] |= directions
+
+	b.from = append(b.from, from)
+	b.to = append(b.to, to)
+	if link.weight > 1 && b.weights == nil {
+		b.weights = make([]int32, len(b.from)-1, cap(b.from))
+		for i := range b.weights {
+			b.weights[i] = 1
+		}
+	}
+	if b.weights != nil {
+		b.weights = append(b.weights, int32(link.weight))
+	}
+	if link.directed && b.oneWay == nil {
+		b.oneWay = make([]bool, len(b.from)-1, cap(b.from))
+	}
+	if b.oneWay != nil {
+		b.oneWay = append(b.oneWay, link.directed)
+	}
+	return nil
+}
+
+// readLine returns the next line of reader including its newline, whatever
+// its length. The returned slice is only valid until the next call.
+func readLine(reader *bufio.Reader, buffer *[]byte) ([]byte, error) {
+	line, err := reader.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		if err == io.EOF && len(line) == 0 {
+			return nil, io.EOF
+		}
+		return line, err
+	}
+
+	// The line is longer than the reader's buffer: accumulate it
+	*buffer = append((*buffer)[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = reader.ReadSlice('\n')
+		*buffer = append(*buffer, line...)
+	}
+	if err == io.EOF && len(*buffer) == 0 {
+		return nil, io.EOF
+	}
+	return *buffer, err
+}
+
+// buildCSR fills the colony's Offsets, Targets and, when some links take
+// more than one turn, Weights from the links collected while parsing,
+// storing each link in both directions unless it is one-way.
+func (b *compactBuilder) buildCSR() {
+	c := b.colony
+	isOneWay := func(i int) bool { return b.oneWay != nil && b.oneWay[i] }
+	c.links = len(b.from)
+	c.Offsets = make([]int32, len(c.Names)+1)
+	for i := range b.from {
+		c.Offsets[b.from[i]+1]++
+		if !isOneWay(i) {
+			c.Offsets[b.to[i]+1]++
+		}
+	}
+	for i := 1; i < len(c.Offsets); i++ {
+		c.Offsets[i] += c.Offsets[i-1]
+	}
+
+	// links holds the index of the link stored in each slot of Targets
+	c.Targets = make([]int32, c.Offsets[len(c.Names)])
+	links := make([]int32, len(c.Targets))
+	next := make([]int32, len(c.Names))
+	copy(next, c.Offsets)
+	for i := range b.from {
+		c.Targets[next[b.from[i]]] = b.to[i]
+		links[next[b.from[i]]] = int32(i)
+		next[b.from[i]]++
+		if isOneWay(i) {
+			continue
+		}
+		c.Targets[next[b.to[i]]] = b.from[i]
+		links[next[b.to[i]]] = int32(i)
+		next[b.to[i]]++
+	}
+
+	for id := range c.Names {
+		start, end := c.Offsets[id], c.Offsets[id+1]
+		sort.Sort(roomLinks{c.Targets[start:end], links[start:end]})
+	}
+
+	if b.weights != nil {
+		c.Weights = make([]int32, len(c.Targets))
+		for slot, link := range links {
+			c.Weights[slot] = b.weights[link]
+		}
+	}
+}
+
+// roomLinks sorts the links of a room by target ID, keeping the index of
+// each link alongside.
+type roomLinks struct {
+	targets, links []int32
+}
+
+func (r roomLinks) Len() int           { return len(r.targets) }
+func (r roomLinks) Less(i, j int) bool { return r.targets[i] < r.targets[j] }
+func (r roomLinks) Swap(i, j int) {
+	r.targets[i], r.targets[j] = r.targets[j], r.targets[i]
+	r.links[i], r.links[j] = r.links[j], r.links[i]
+}
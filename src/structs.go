@@ -2,11 +2,16 @@ package src
 
 // Room represents a single room in the ant colony.
 type Room struct {
-	Name    string   // Name of the room
-	X, Y    int      // Coordinates of the room
-	IsStart bool     // Indicates if this is the start room
-	IsEnd   bool     // Indicates if this is the end room
-	Links   []string // Names of rooms connected to this rooms
+	Name     string   // Name of the room
+	X, Y     int      // Coordinates of the room
+	IsStart  bool     // Indicates if this is the start room
+	IsEnd    bool     // Indicates if this is the end room
+	Links    []string // Names of rooms reachable from this room through a tunnel
+	Capacity int      // Number of ants the room can hold at once (start and end hold any number)
+
+	// Weights holds the number of turns needed to cross the tunnels to
+	// linked rooms, for the tunnels taking more than one turn
+	Weights map[string]int
 }
 
 // LemInData holds all the information about the ant colony and its configuration.
@@ -14,8 +19,12 @@ type LemInData struct {
 	NumAnts     int              // Total number of ants
 	TabAntNames []string         // Names of all ants
 	Rooms       map[string]*Room // Map of all rooms, keyed by room name
-	StartRoom   string           // Name of the start room
-	EndRoom     string           // Name of the end room
+	StartRoom   string           // Name of the start room (the first one when there are several)
+	EndRoom     string           // Name of the end room (the first one when there are several)
+	StartRooms  []string         // Names of the start rooms, in input order
+	EndRooms    []string         // Names of the end rooms, in input order
+	StartAnts   map[string]int   // Ants leaving from each start room, nil when they all leave from StartRoom
+	Lines       []string         // Lines of the input, as read
 }
 
 // NewLemInData creates and initializes a new LemInData struct.
@@ -26,37 +35,220 @@ func NewLemInData() *LemInData {
 }
 
 // AddRoom adds a new room to the LemInData struct.
-func (l *LemInData) AddRoom(name string, x, y int) {
+func (l *LemInData) AddRoom(name string, x, y int) error {
+	if _, exists := l.Rooms[name]; exists {
+		return ErrDuplicateRoom
+	}
 	l.Rooms[name] = &Room{
-		Name:  name,
-		X:     x,
-		Y:     y,
-		Links: []string{},
+		Name:     name,
+		X:        x,
+		Y:        y,
+		Links:    []string{},
+		Capacity: 1,
 	}
+	return nil
 }
 
-// SetStartRoom marks a room as the start room.
+// SetRoomCapacity sets the number of ants a room can hold at once.
+func (l *LemInData) SetRoomCapacity(name string, capacity int) {
+	if room, exists := l.Rooms[name]; exists {
+		room.Capacity = capacity
+	}
+}
+
+// SetStartRoom marks a room as a start room. The first one becomes StartRoom.
 func (l *LemInData) SetStartRoom(name string) {
 	if room, exists := l.Rooms[name]; exists {
 		room.IsStart = true
-		l.StartRoom = name
+		if l.StartRoom == "" {
+			l.StartRoom = name
+		}
+		l.StartRooms = append(l.StartRooms, name)
 	}
 }
 
-// SetEndRoom marks a room as the end room.
+// SetEndRoom marks a room as an end room. The first one becomes EndRoom.
 func (l *LemInData) SetEndRoom(name string) {
 	if room, exists := l.Rooms[name]; exists {
 		room.IsEnd = true
-		l.EndRoom = name
+		if l.EndRoom == "" {
+			l.EndRoom = name
+		}
+		l.EndRooms = append(l.EndRooms, name)
+	}
+}
+
+// Starts returns the names of the start rooms.
+func (l *LemInData) Starts() []string {
+	if len(l.StartRooms) == 0 && l.StartRoom != "" {
+		return []string{l.StartRoom}
 	}
+	return l.StartRooms
+}
+
+// Ends returns the names of the end rooms.
+func (l *LemInData) Ends() []string {
+	if len(l.EndRooms) == 0 && l.EndRoom != "" {
+		return []string{l.EndRoom}
+	}
+	return l.EndRooms
+}
+
+// AntsAt returns the number of ants leaving from a start room.
+func (l *LemInData) AntsAt(start string) int {
+	if l.StartAnts == nil {
+		if start == l.StartRoom {
+			return l.NumAnts
+		}
+		return 0
+	}
+	return l.StartAnts[start]
+}
+
+// AntStarts returns the start room of each ant, indexed by ant number. The
+// ants are numbered start room by start room, in input order.
+func (l *LemInData) AntStarts() []string {
+	starts := make([]string, 1, l.NumAnts+1)
+	for _, start := range l.Starts() {
+		for i := 0; i < l.AntsAt(start); i++ {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// isEnd reports whether a room is an end room.
+func (l *LemInData) isEnd(name string) bool {
+	room := l.Rooms[name]
+	return name == l.EndRoom || (room != nil && room.IsEnd)
+}
+
+// isTerminal reports whether a room is a start or an end room, which hold
+// any number of ants.
+func (l *LemInData) isTerminal(name string) bool {
+	room := l.Rooms[name]
+	return name == l.StartRoom || name == l.EndRoom || (room != nil && (room.IsStart || room.IsEnd))
 }
 
 // AddLink creates a bidirectional link between two rooms.
-func (l *LemInData) AddLink(room1, room2 string) {
-	if r1, exists := l.Rooms[room1]; exists {
-		r1.Links = append(r1.Links, room2)
+func (l *LemInData) AddLink(room1, room2 string) error {
+	r1, exists := l.Rooms[room1]
+	if !exists {
+		return ErrUnknownRoom
+	}
+	r2, exists := l.Rooms[room2]
+	if !exists {
+		return ErrUnknownRoom
+	}
+	if Contains(r1.Links, room2) || Contains(r2.Links, room1) {
+		return ErrDuplicateLink
+	}
+	r1.Links = append(r1.Links, room2)
+	r2.Links = append(r2.Links, room1)
+	return nil
+}
+
+// AddDirectedLink creates a one-way link from a room to another.
+func (l *LemInData) AddDirectedLink(from, to string) error {
+	r1, exists := l.Rooms[from]
+	if !exists {
+		return ErrUnknownRoom
 	}
-	if r2, exists := l.Rooms[room2]; exists {
-		r2.Links = append(r2.Links, room1)
+	if _, exists := l.Rooms[to]; !exists {
+		return ErrUnknownRoom
 	}
+	if Contains(r1.Links, to) {
+		return ErrDuplicateLink
+	}
+	r1.Links = append(r1.Links, to)
+	return nil
+}
+
+// hasRoom reports whether a room of that name exists, for lineParser.
+func (l *LemInData) hasRoom(name string) bool {
+	_, exists := l.Rooms[name]
+	return exists
+}
+
+// addRoom adds a room read by lineParser.
+func (l *LemInData) addRoom(name string, x, y, capacity int, start, end bool) {
+	l.AddRoom(name, x, y)
+	if capacity != 0 {
+		l.SetRoomCapacity(name, capacity)
+	}
+	if start {
+		l.SetStartRoom(name)
+	} else if end {
+		l.SetEndRoom(name)
+	}
+}
+
+// addLink adds a link read by lineParser.
+func (l *LemInData) addLink(link linkDef, lineNumber int) error {
+	if link.directed {
+		if err := l.AddDirectedLink(link.from, link.to); err != nil {
+			return err
+		}
+		if link.weight > 1 {
+			l.setDirectedWeight(link.from, link.to, link.weight)
+		}
+		return nil
+	}
+	if err := l.AddLink(link.from, link.to); err != nil {
+		return err
+	}
+	if link.weight > 1 {
+		l.SetLinkWeight(link.from, link.to, link.weight)
+	}
+	return nil
+}
+
+// OneWay reports whether the link from a room to another can only be
+// crossed in that direction.
+func (l *LemInData) OneWay(from, to string) bool {
+	r1, r2 := l.Rooms[from], l.Rooms[to]
+	return r1 != nil && r2 != nil && Contains(r1.Links, to) && !Contains(r2.Links, from)
 }
+
+// SetLinkWeight sets the number of turns needed to cross the link from a
+// room to another, and back when the link is two-way.
+func (l *LemInData) SetLinkWeight(room1, room2 string, weight int) {
+	l.setDirectedWeight(room1, room2, weight)
+	if !l.OneWay(room1, room2) {
+		l.setDirectedWeight(room2, room1, weight)
+	}
+}
+
+// setDirectedWeight sets the number of turns needed to cross the link from a
+// room to another, in that direction only.
+func (l *LemInData) setDirectedWeight(from, to string, weight int) {
+	r1, r2 := l.Rooms[from], l.Rooms[to]
+	if r1 == nil || r2 == nil {
+		return
+	}
+	if r1.Weights == nil {
+		r1.Weights = make(map[string]int)
+	}
+	r1.Weights[to] = weight
+}
+
+// LinkWeight returns the number of turns needed to cross the link between
+// two rooms, which is 1 unless set with SetLinkWeight.
+func (l *LemInData) LinkWeight(room1, room2 string) int {
+	if r, exists := l.Rooms[room1]; exists {
+		if weight, ok := r.Weights[room2]; ok {
+			return weight
+		}
+	}
+	return 1
+}
+
+// Move is a single ant stepping from one room to an adjacent one.
+type Move struct {
+	Ant  int    // Number of the ant (1 for L1, 2 for L2, ...)
+	From string // Name of the room the ant leaves
+	To   string // Name of the room the ant enters
+}
+
+// Turn holds every move made during one turn of the simulation.
+type Turn []Move
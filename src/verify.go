@@ -0,0 +1,263 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// VerifyError describes the first rule violation found in a move transcript.
+type VerifyError struct {
+	Turn int    // Turn where the violation happened, starting at 1
+	Move string // Offending move as written in the transcript, if any
+	Msg  string // Description of the violation
+}
+
+func (e *VerifyError) Error() string {
+	if e.Move != "" {
+		return fmt.Sprintf("turn %d: %s: %s", e.Turn, e.Move, e.Msg)
+	}
+	return fmt.Sprintf("turn %d: %s", e.Turn, e.Msg)
+}
+
+// Verify replays a move transcript read from r against the colony and
+// returns the number of turns it takes, or a *VerifyError describing the
+// first rule violation. The transcript is one line of "Lx-room" moves per
+// turn, each move written on the turn the ant enters the room; it may be
+// preceded by the echoed input file and a blank line, as produced by the
+// strict output mode. A blank line is a turn where no ant enters a room,
+// which happens while ants are crossing tunnels that take several turns.
+func Verify(l *LemInData, r io.Reader) (int, error) {
+	return VerifyCapacity(l, r, VertexAndEdgeCapacity)
+}
+
+// VerifyCapacity is Verify under the given capacity model: the room or the
+// tunnel rule is only checked when the model limits rooms or tunnels.
+func VerifyCapacity(l *LemInData, r io.Reader, capacity CapacityModel) (int, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(lines) > 0 && lines[0] != "" && !strings.HasPrefix(lines[0], "L") {
+		lines = lines[l.movesStart(lines):]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	antRooms := l.AntStarts()
+	arrivals := make([]int, l.NumAnts+1)
+
+	// An ant crossing a tunnel of weight w leaves its room w-1 turns before
+	// its move is written, so the rooms of a turn are only checked once the
+	// moves of the following w-1 turns are read
+	delay := l.maxLinkWeight() - 1
+	events := make(map[int]*turnEvents)
+	eventsAt := func(turn int) *turnEvents {
+		if events[turn] == nil {
+			events[turn] = &turnEvents{tunnels: make(map[string]bool)}
+		}
+		return events[turn]
+	}
+	occupants := make(map[string]int)
+	check := func(from, to int) *VerifyError {
+		for turn := max(from, 1); turn <= to; turn++ {
+			if !capacity.LimitsRooms() {
+				delete(events, turn)
+				continue
+			}
+			if err := l.checkRooms(turn, events[turn], occupants); err != nil {
+				return err
+			}
+			delete(events, turn)
+		}
+		return nil
+	}
+
+	for i, line := range lines {
+		turn := i + 1
+		if err := l.verifyTurn(turn, strings.Fields(line), capacity, antRooms, arrivals, eventsAt); err != nil {
+			if roomErr := check(turn-delay, turn-1); roomErr != nil {
+				return roomErr.Turn, roomErr
+			}
+			return turn, err
+		}
+		if err := check(turn-delay, turn-delay); err != nil {
+			return err.Turn, err
+		}
+	}
+	if err := check(len(lines)-delay+1, len(lines)); err != nil {
+		return err.Turn, err
+	}
+
+	turn := len(lines)
+	target := "end room " + l.EndRoom
+	if len(l.Ends()) > 1 {
+		target = "an end room"
+	}
+	for ant := 1; ant <= l.NumAnts; ant++ {
+		if !l.isEnd(antRooms[ant]) {
+			return turn, &VerifyError{
+				Turn: turn,
+				Msg:  fmt.Sprintf("ant L%d did not reach %s (stopped in %s)", ant, target, antRooms[ant]),
+			}
+		}
+	}
+	return turn, nil
+}
+
+// movesStart returns the index of the first turn in a transcript that begins
+// with the echoed input file. The input file may itself contain blank lines,
+// so the echo is recognized as the colony's own input lines followed by a
+// blank line. Failing that, it ends at the first blank line whose next
+// non-blank line starts with a move, or else at the first blank line.
+func (l *LemInData) movesStart(lines []string) int {
+	if len(lines) > len(l.Lines) && lines[len(l.Lines)] == "" && slices.Equal(lines[:len(l.Lines)], l.Lines) {
+		return len(l.Lines) + 1
+	}
+	firstBlank := -1
+	for i, line := range lines {
+		if line != "" {
+			continue
+		}
+		if firstBlank < 0 {
+			firstBlank = i
+		}
+		for _, next := range lines[i+1:] {
+			if fields := strings.Fields(next); len(fields) > 0 {
+				if _, _, ok := parseMove(fields[0]); ok {
+					return i + 1
+				}
+				break
+			}
+		}
+	}
+	return firstBlank + 1
+}
+
+// turnEvents holds what happens to the rooms and tunnels during one turn.
+type turnEvents struct {
+	left    []string        // Intermediate rooms left by an ant
+	entered []string        // Intermediate rooms entered by an ant, in transcript order
+	moves   []string        // Move entering each room of entered
+	tunnels map[string]bool // Tunnels entered by an ant
+}
+
+// verifyTurn applies the moves of one turn to antRooms and to arrivals, the
+// turn each ant entered its room, checking each of them, and the tunnel rule
+// when capacity limits tunnels. The rooms and tunnels used are recorded in
+// the events of the turns they are used on.
+func (l *LemInData) verifyTurn(turn int, moves []string, capacity CapacityModel, antRooms []string, arrivals []int, eventsAt func(int) *turnEvents) error {
+	moved := make(map[int]bool)
+
+	for _, token := range moves {
+		fail := func(format string, args ...interface{}) error {
+			return &VerifyError{Turn: turn, Move: token, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		ant, room, ok := parseMove(token)
+		if !ok {
+			return fail("malformed move")
+		}
+		if ant < 1 || ant > l.NumAnts {
+			return fail("unknown ant")
+		}
+		if _, exists := l.Rooms[room]; !exists {
+			return fail("unknown room %s", room)
+		}
+		if moved[ant] {
+			return fail("ant L%d moves twice in the same turn", ant)
+		}
+		from := antRooms[ant]
+		if l.isEnd(from) {
+			return fail("ant L%d has already reached an end room", ant)
+		}
+		if !Contains(l.Rooms[from].Links, room) {
+			return fail("room %s is not adjacent to %s", room, from)
+		}
+		weight := l.LinkWeight(from, room)
+		departure := turn - weight + 1
+		if departure <= arrivals[ant] {
+			return fail("ant L%d cannot reach %s before turn %d: the tunnel from %s takes %d turns", ant, room, arrivals[ant]+weight, from, weight)
+		}
+		link := linkKey(from, room)
+		leaving := eventsAt(departure)
+		if capacity.LimitsTunnels() && leaving.tunnels[link] {
+			return fail("tunnel %s is used twice in the same turn", link)
+		}
+		leaving.tunnels[link] = true
+		if !l.isTerminal(from) {
+			leaving.left = append(leaving.left, from)
+		}
+		if !l.isTerminal(room) {
+			entering := eventsAt(turn)
+			entering.entered = append(entering.entered, room)
+			entering.moves = append(entering.moves, token)
+		}
+
+		moved[ant] = true
+		antRooms[ant] = room
+		arrivals[ant] = turn
+	}
+	return nil
+}
+
+// checkRooms applies the rooms left and entered during a turn to occupants,
+// the number of ants in each intermediate room, and checks that no room
+// holds more ants than its capacity once every ant has moved.
+func (l *LemInData) checkRooms(turn int, events *turnEvents, occupants map[string]int) *VerifyError {
+	if events == nil {
+		return nil
+	}
+	for _, room := range events.left {
+		occupants[room]--
+	}
+	for i, room := range events.entered {
+		occupants[room]++
+		if capacity := max(l.Rooms[room].Capacity, 1); occupants[room] > capacity {
+			return &VerifyError{
+				Turn: turn,
+				Move: events.moves[i],
+				Msg:  fmt.Sprintf("room %s holds %d ants, more than its capacity of %d", room, occupants[room], capacity),
+			}
+		}
+	}
+	return nil
+}
+
+// maxLinkWeight returns the number of turns needed to cross the longest
+// tunnel of the colony.
+func (l *LemInData) maxLinkWeight() int {
+	longest := 1
+	for _, room := range l.Rooms {
+		for _, weight := range room.Weights {
+			longest = max(longest, weight)
+		}
+	}
+	return longest
+}
+
+// parseMove splits a "Lx-room" token into the ant number and the room name.
+func parseMove(token string) (int, string, bool) {
+	if !strings.HasPrefix(token, "L") {
+		return 0, "", false
+	}
+	number, room, found := strings.Cut(token[1:], "-")
+	if !found || room == "" {
+		return 0, "", false
+	}
+	ant, err := strconv.Atoi(number)
+	if err != nil {
+		return 0, "", false
+	}
+	return ant, room, true
+}
@@ -0,0 +1,116 @@
+package src
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const verifyColony = `3
+##start
+s 0 0
+a 1 0
+b 1 1
+##end
+e 2 0
+s-a
+s-b
+a-e
+b-e
+`
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		moves  string
+		turns  int
+		errMsg string // Part of the expected VerifyError message, empty if valid
+	}{
+		{"valid", "L1-a L2-b\nL1-e L2-e L3-a\nL3-e\n", 3, ""},
+		{"echoed input", verifyColony + "\nL1-a L2-b\nL1-e L2-e L3-a\nL3-e\n", 3, ""},
+		{"malformed move", "L1-a Lx\n", 1, "malformed move"},
+		{"unknown ant", "L4-a\n", 1, "unknown ant"},
+		{"unknown room", "L1-z\n", 1, "unknown room z"},
+		{"moves twice", "L1-a L1-e\n", 1, "moves twice"},
+		{"not adjacent", "L1-e\n", 1, "not adjacent"},
+		{"room full", "L1-a L2-b\nL3-a\n", 2, "room a holds 2 ants"},
+		{"tunnel used twice", "L1-a L2-a\n", 1, "tunnel a-s is used twice"},
+		{"already arrived", "L1-a\nL1-e\nL1-a\n", 3, "already reached"},
+		{"ant left behind", "L1-a L2-b\nL1-e L2-e\n", 2, "ant L3 did not reach end room e"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, err := ParseInput(strings.NewReader(verifyColony))
+			if err != nil {
+				t.Fatal(err)
+			}
+			turns, err := Verify(l, strings.NewReader(test.moves))
+			if test.errMsg == "" {
+				if err != nil || turns != test.turns {
+					t.Errorf("Verify = %d, %v; want %d, nil", turns, err, test.turns)
+				}
+				return
+			}
+			var verifyErr *VerifyError
+			if !errors.As(err, &verifyErr) || !strings.Contains(verifyErr.Msg, test.errMsg) {
+				t.Fatalf("Verify error = %v; want a *VerifyError containing %q", err, test.errMsg)
+			}
+			if verifyErr.Turn != test.turns {
+				t.Errorf("Verify error on turn %d; want turn %d", verifyErr.Turn, test.turns)
+			}
+		})
+	}
+}
+
+// TestVerifyEchoedInputWithBlankLines checks that the moves are found after
+// an echoed input file that contains blank lines itself.
+func TestVerifyEchoedInputWithBlankLines(t *testing.T) {
+	input := strings.Replace(verifyColony, "a 1 0\n", "a 1 0\n\n", 1)
+	l, err := ParseInput(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	turns, err := Verify(l, strings.NewReader(input+"\nL1-a L2-b\nL1-e L2-e L3-a\nL3-e\n"))
+	if err != nil || turns != 3 {
+		t.Errorf("Verify = %d, %v; want 3, nil", turns, err)
+	}
+}
+
+// TestVerifyCapacity checks that each capacity model lifts the room or the
+// tunnel rule only.
+func TestVerifyCapacity(t *testing.T) {
+	const directColony = "3\n##start\ns 0 0\n##end\ne 1 0\ns-e\n"
+	tests := []struct {
+		name   string
+		input  string
+		moves  string
+		errMsg map[CapacityModel]string // Part of the expected error per model, empty if valid
+	}{
+		{"shared room", verifyColony, "L1-a\nL2-a\nL1-e L3-a\nL2-e\nL3-e\n", map[CapacityModel]string{
+			VertexAndEdgeCapacity: "room a holds 2 ants",
+			VertexCapacity:        "room a holds 2 ants",
+			EdgeCapacity:          "",
+		}},
+		{"shared tunnel", directColony, "L1-e L2-e L3-e\n", map[CapacityModel]string{
+			VertexAndEdgeCapacity: "tunnel e-s is used twice",
+			VertexCapacity:        "",
+			EdgeCapacity:          "tunnel e-s is used twice",
+		}},
+	}
+	for _, test := range tests {
+		for capacity, errMsg := range test.errMsg {
+			t.Run(test.name+"/"+capacity.String(), func(t *testing.T) {
+				l, err := ParseInput(strings.NewReader(test.input))
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = VerifyCapacity(l, strings.NewReader(test.moves), capacity)
+				if errMsg == "" && err != nil {
+					t.Errorf("VerifyCapacity = %v; want nil", err)
+				} else if errMsg != "" && (err == nil || !strings.Contains(err.Error(), errMsg)) {
+					t.Errorf("VerifyCapacity = %v; want an error containing %q", err, errMsg)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"lem-in/src"
+	"os"
+	"strings"
+)
+
+// runVerify implements "lem-in verify [-capacity model] <map> <moves>": it
+// replays a move transcript against a map and reports the first rule
+// violation under the given capacity model.
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	capacity := flags.String("capacity", "both", "rooms and/or tunnels limited to one ant at a time: vertex, edge or both")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: lem-in verify [-capacity model] <map> <moves>")
+		return 2
+	}
+	capacityModel, err := src.ParseCapacityModel(*capacity)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	lemInData, err := src.ParseInputFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error parsing file:", err)
+		return 1
+	}
+
+	movesFile, err := os.Open(flags.Arg(1))
+	if err != nil {
+		fmt.Println("Error opening moves:", err)
+		return 1
+	}
+	defer movesFile.Close()
+
+	turns, err := src.VerifyCapacity(lemInData, movesFile, capacityModel)
+	if err != nil {
+		fmt.Println("INVALID:", err)
+		return 1
+	}
+	fmt.Printf("OK: %d ants reached %s in %d turns\n", lemInData.NumAnts, strings.Join(lemInData.Ends(), ", "), turns)
+	return 0
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"lem-in/lemin"
+	"lem-in/src"
+	"lem-in/visualizer"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// runVisualize implements "lem-in visualize [-o dir] [-format dot|svg|png] <map>":
+// it solves a map and writes one file per turn showing where the ants are,
+// as a Graphviz DOT file or drawn directly as an SVG or PNG image. With
+// -animate it writes a single animated GIF of the whole simulation instead,
+// with -html a self-contained page replaying it, and with -tui it plays the
+// simulation in the terminal. The DOT output is configured with -template,
+// -scale, -size, -dpi, -theme and -combined, and -clean removes the step
+// files of a previous run first.
+func runVisualize(args []string) int {
+	flags := flag.NewFlagSet("visualize", flag.ContinueOnError)
+	dir := flags.String("o", ".", "directory where the step_N files are written")
+	format := flags.String("format", "dot", "format of the step files: dot, svg or png")
+	animate := flags.String("animate", "", "write an animated GIF of the simulation to this file instead of step files")
+	frames := flags.Int("frames", 4, "images per turn in the animated GIF")
+	page := flags.String("html", "", "write an offline HTML replay of the simulation to this file instead of step files")
+	tui := flags.Bool("tui", false, "play the simulation in the terminal, stepping with the arrow keys")
+	template := flags.String("template", "step_%d.dot", "DOT file name, %d being the turn index (%03d pads it)")
+	scale := flags.Int("scale", 100, "factor applied to the room coordinates in DOT files")
+	size := flags.String("size", "10,7.5", "DOT image size in inches, width,height")
+	dpi := flags.Int("dpi", 96, "DOT image resolution")
+	theme := flags.String("theme", "default", "DOT color theme: default, dark or mono")
+	combined := flags.String("combined", "", "write a single DOT file with one subgraph per turn, named this, instead of one file per turn")
+	clean := flags.Bool("clean", false, "remove the step files left by a previous run first (for DOT, the files matching -template)")
+	capacity := flags.String("capacity", "both", "rooms and/or tunnels limited to one ant at a time: vertex, edge or both")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: lem-in visualize [-o dir] [-format dot|svg|png] [-animate out.gif [-frames n]] [-html out.html] [-tui] [DOT options] [-capacity model] <map>")
+		return 2
+	}
+
+	capacityModel, err := src.ParseCapacityModel(*capacity)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	dotOptions := visualizer.DOTOptions{
+		Dir:      *dir,
+		Template: *template,
+		Scale:    *scale,
+		Size:     *size,
+		DPI:      *dpi,
+		Theme:    *theme,
+		Combined: *combined,
+		Clean:    *clean,
+	}
+	if err := dotOptions.Check(); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	lemInData, err := src.ParseInputFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println("Error parsing file:", err)
+		return 1
+	}
+	solution, err := lemin.SolveData(lemInData, lemin.Options{Capacity: capacityModel})
+	if err != nil {
+		fmt.Println("Error solving map:", err)
+		return 1
+	}
+
+	if *tui {
+		if err := runTerminal(lemInData, solution.Turns); err != nil {
+			fmt.Println("Error running terminal visualizer:", err)
+			return 1
+		}
+		return 0
+	}
+
+	if *animate != "" || *page != "" {
+		var written []string
+		if *animate != "" {
+			err = writeOutput(*animate, func(w io.Writer) error {
+				return visualizer.WriteGIF(w, lemInData, solution.Turns, *frames)
+			})
+			written = append(written, *animate)
+		}
+		if err == nil && *page != "" {
+			err = writeOutput(*page, func(w io.Writer) error {
+				return visualizer.WriteHTML(w, lemInData, solution.Paths, solution.Turns)
+			})
+			written = append(written, *page)
+		}
+		if err != nil {
+			fmt.Println("Error writing visualization:", err)
+			return 1
+		}
+		fmt.Printf("Wrote %d turns to %s\n", len(solution.Turns), strings.Join(written, " and "))
+		return 0
+	}
+
+	var files []string
+	if *format == "dot" {
+		files, err = dotOptions.WriteFiles(lemInData, solution.Turns)
+	} else {
+		if *clean {
+			// The SVG and PNG step files are always named step_N.<format>
+			_, err = visualizer.DOTOptions{Dir: *dir, Template: "step_%d." + *format}.RemoveStale()
+		}
+		if err == nil {
+			files, err = visualizer.WriteStepFiles(*dir, *format, lemInData, solution.Turns)
+		}
+	}
+	if err != nil {
+		fmt.Println("Error writing step files:", err)
+		return 1
+	}
+	if *format == "dot" && *combined != "" {
+		fmt.Printf("Wrote %d turns to %s\n", len(solution.Turns), files[0])
+	} else {
+		fmt.Printf("Wrote %d %s files to %s\n", len(files), strings.ToUpper(*format), *dir)
+	}
+	return 0
+}
+
+// writeOutput creates fileName and fills it with write.
+func writeOutput(fileName string, write func(io.Writer) error) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runTerminal plays turns in the terminal. It switches the terminal to
+// cbreak mode with stty so that keys act without Enter, and falls back to
+// line mode when that fails (no stty, or stdin is not a terminal).
+func runTerminal(lemInData *src.LemInData, turns []src.Turn) error {
+	width, height := terminalSize()
+	terminal := visualizer.NewTerminal(lemInData, turns, width, height)
+
+	if saved, err := stty("-g"); err == nil {
+		if _, err := stty("cbreak", "-echo"); err == nil {
+			restore := func() {
+				stty(strings.TrimSpace(saved))
+				fmt.Print("\x1b[?25h") // Show the cursor again
+			}
+			defer restore()
+
+			// Ctrl-C still interrupts in cbreak mode: restore the terminal first
+			interrupts := make(chan os.Signal, 1)
+			signal.Notify(interrupts, os.Interrupt)
+			defer signal.Stop(interrupts)
+			go func() {
+				if _, ok := <-interrupts; ok {
+					restore()
+					os.Exit(130)
+				}
+			}()
+			fmt.Print("\x1b[?25l") // Hide the cursor
+		} else {
+			terminal.LineMode = true
+		}
+	} else {
+		terminal.LineMode = true
+	}
+	return terminal.Run(os.Stdin, os.Stdout)
+}
+
+// stty runs stty on the terminal attached to stdin and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// terminalSize returns the number of columns and rows of the terminal, from
+// stty or the COLUMNS and LINES variables, or 80x24 if neither is available.
+func terminalSize() (int, int) {
+	if out, err := stty("size"); err == nil {
+		var rows, columns int
+		if _, err := fmt.Sscan(out, &rows, &columns); err == nil && rows > 0 && columns > 0 {
+			return columns, rows
+		}
+	}
+	columns, err1 := strconv.Atoi(os.Getenv("COLUMNS"))
+	rows, err2 := strconv.Atoi(os.Getenv("LINES"))
+	if err1 == nil && err2 == nil && columns > 0 && rows > 0 {
+		return columns, rows
+	}
+	return 80, 24
+}
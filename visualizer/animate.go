@@ -0,0 +1,181 @@
+package visualizer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"lem-in/src"
+	"sort"
+)
+
+// keyframe est la salle où se trouve une fourmi à un instant donné, mesuré
+// en tours (0 avant le premier tour, 1 à la fin du premier, ...).
+type keyframe struct {
+	time float64
+	room string
+}
+
+// antTracks renvoie, pour chaque fourmi (l'indice 0 n'est pas utilisé), ses
+// positions successives. Une fourmi qui arrive dans une salle au tour T par
+// un tunnel de longueur w l'a quittée à la fin du tour T-w : elle est
+// dessinée entre les deux salles pendant sa traversée.
+func antTracks(lemInData *src.LemInData, turns []src.Turn) [][]keyframe {
+	starts := lemInData.AntStarts()
+	tracks := make([][]keyframe, lemInData.NumAnts+1)
+	for ant := 1; ant <= lemInData.NumAnts; ant++ {
+		tracks[ant] = []keyframe{{time: 0, room: starts[ant]}}
+	}
+	for turn, moves := range turns {
+		arrival := float64(turn + 1)
+		for _, move := range moves {
+			track := tracks[move.Ant]
+			departure := arrival - float64(lemInData.LinkWeight(move.From, move.To))
+			if last := track[len(track)-1].time; departure < last {
+				departure = last
+			}
+			tracks[move.Ant] = append(track, keyframe{time: departure, room: move.From}, keyframe{time: arrival, room: move.To})
+		}
+	}
+	return tracks
+}
+
+// trackPosition renvoie où se trouve une fourmi à l'instant time : dans la
+// salle from si progress vaut 0, sinon dans le tunnel from-to, à la fraction
+// progress du trajet.
+func trackPosition(track []keyframe, time float64) (from, to string, progress float64) {
+	// Première position postérieure à time
+	i := sort.Search(len(track), func(i int) bool { return track[i].time > time })
+	if i == 0 {
+		return track[0].room, "", 0
+	}
+	if i == len(track) {
+		return track[i-1].room, "", 0
+	}
+	previous, next := track[i-1], track[i]
+	if previous.room == next.room || previous.time == time {
+		return previous.room, "", 0
+	}
+	return previous.room, next.room, (time - previous.time) / (next.time - previous.time)
+}
+
+// animatedScene place les fourmis à l'instant time : celles qui sont dans une
+// salle comme dans staticScene, celles qui traversent un tunnel entre les
+// centres des deux salles.
+func animatedScene(lemInData *src.LemInData, l layout, tracks [][]keyframe, time float64) scene {
+	antRooms := make(map[int]string)
+	var moving []antMark
+	for ant := 1; ant < len(tracks); ant++ {
+		from, to, progress := trackPosition(tracks[ant], time)
+		if to == "" {
+			antRooms[ant] = from
+			continue
+		}
+		a, b := l.position(lemInData.Rooms[from]), l.position(lemInData.Rooms[to])
+		moving = append(moving, antMark{ant: ant, at: point{X: a.X + (b.X-a.X)*progress, Y: a.Y + (b.Y-a.Y)*progress}})
+	}
+	s := staticScene(lemInData, l, antRooms)
+	s.ants = append(s.ants, moving...)
+	return s
+}
+
+// WriteGIF écrit une animation GIF de toute la simulation : chaque tour dure
+// une seconde et compte framesPerTurn images, les fourmis étant dessinées
+// entre deux salles pendant qu'elles traversent un tunnel.
+func WriteGIF(w io.Writer, lemInData *src.LemInData, turns []src.Turn, framesPerTurn int) error {
+	if framesPerTurn < 1 {
+		return fmt.Errorf("invalid number of frames per turn: %d", framesPerTurn)
+	}
+	l := newLayout(lemInData, imageWidth, imageHeight)
+	tracks := antTracks(lemInData, turns)
+	colors, index := gifPalette()
+
+	anim := &gif.GIF{Config: image.Config{ColorModel: colors, Width: imageWidth, Height: imageHeight}}
+	var previous *image.Paletted
+	addFrame := func(time float64, turn int, delay int) {
+		img := drawScene(lemInData, l, animatedScene(lemInData, l, tracks, time))
+		drawText(img, fmt.Sprintf("%d/%d", turn, len(turns)), point{X: 40, Y: 12}, 3, palette["black"])
+		frame := toPaletted(img, colors, index)
+		if previous == nil {
+			anim.Image = append(anim.Image, frame)
+		} else {
+			// Seule la zone qui a changé depuis l'image précédente est
+			// enregistrée, par-dessus celle-ci
+			anim.Image = append(anim.Image, frame.SubImage(changedBounds(previous, frame)).(*image.Paletted))
+		}
+		anim.Delay = append(anim.Delay, delay)
+		previous = frame
+	}
+
+	// État initial, puis les images intermédiaires de chaque tour
+	addFrame(0, 0, 100)
+	for turn := 1; turn <= len(turns); turn++ {
+		for frame := 1; frame <= framesPerTurn; frame++ {
+			delay := 100 / framesPerTurn
+			if turn == len(turns) && frame == framesPerTurn {
+				delay = 300 // On s'attarde sur l'état final avant de reboucler
+			}
+			addFrame(float64(turn-1)+float64(frame)/float64(framesPerTurn), turn, delay)
+		}
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// gifPalette renvoie la palette des images GIF, formée des couleurs de
+// palette dans un ordre stable, et l'indice de chaque couleur.
+func gifPalette() (color.Palette, map[color.RGBA]uint8) {
+	names := make([]string, 0, len(palette))
+	for name := range palette {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	colors := make(color.Palette, len(names))
+	index := make(map[color.RGBA]uint8, len(names))
+	for i, name := range names {
+		colors[i] = palette[name]
+		index[palette[name]] = uint8(i)
+	}
+	return colors, index
+}
+
+// toPaletted convertit une image dessinée par drawScene, qui n'utilise que
+// les couleurs de palette, en image à palette.
+func toPaletted(img *image.RGBA, colors color.Palette, index map[color.RGBA]uint8) *image.Paletted {
+	out := image.NewPaletted(img.Bounds(), colors)
+	last, lastIndex := color.RGBA{}, uint8(0)
+	for i := range out.Pix {
+		pixel := img.Pix[4*i : 4*i+4]
+		c := color.RGBA{pixel[0], pixel[1], pixel[2], pixel[3]}
+		// Les pixels voisins ont souvent la même couleur : on évite de
+		// consulter index pour chacun
+		if c != last || i == 0 {
+			last, lastIndex = c, index[c]
+		}
+		out.Pix[i] = lastIndex
+	}
+	return out
+}
+
+// changedBounds renvoie le plus petit rectangle contenant les pixels qui
+// diffèrent entre previous et current, ou un seul pixel s'ils sont identiques
+// (une image GIF ne peut pas être vide).
+func changedBounds(previous, current *image.Paletted) image.Rectangle {
+	bounds := current.Bounds()
+	minX, minY, maxX, maxY := bounds.Max.X, bounds.Max.Y, bounds.Min.X-1, bounds.Min.Y-1
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := (y - bounds.Min.Y) * current.Stride
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := row + x - bounds.Min.X
+			if previous.Pix[i] != current.Pix[i] {
+				minX, maxX = min(minX, x), max(maxX, x)
+				minY, maxY = min(minY, y), max(maxY, y)
+			}
+		}
+	}
+	if maxX < minX {
+		return image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+1, bounds.Min.Y+1)
+	}
+	return image.Rect(minX, minY, maxX+1, maxY+1)
+}
@@ -0,0 +1,40 @@
+package visualizer
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+)
+
+func TestWriteGIF(t *testing.T) {
+	solution := solveColony(t)
+	const framesPerTurn = 4
+	var out bytes.Buffer
+	if err := WriteGIF(&out, solution.Data, solution.Turns, framesPerTurn); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&out)
+	if err != nil {
+		t.Fatalf("invalid GIF: %v", err)
+	}
+	if anim.Config.Width != imageWidth || anim.Config.Height != imageHeight {
+		t.Errorf("animation of %dx%d; want %dx%d", anim.Config.Width, anim.Config.Height, imageWidth, imageHeight)
+	}
+
+	// L'état initial, puis framesPerTurn images par tour, chaque tour durant
+	// une seconde et l'état final trois
+	if want := 1 + len(solution.Turns)*framesPerTurn; len(anim.Image) != want {
+		t.Errorf("%d frames; want %d", len(anim.Image), want)
+	}
+	duration := 0
+	for _, delay := range anim.Delay {
+		duration += delay
+	}
+	if want := 100*(len(solution.Turns)+1) + 300 - 100/framesPerTurn; duration != want {
+		t.Errorf("animation lasts %d hundredths of a second; want %d", duration, want)
+	}
+
+	if err := WriteGIF(&out, solution.Data, solution.Turns, 0); err == nil {
+		t.Error("WriteGIF accepted 0 frames per turn")
+	}
+}
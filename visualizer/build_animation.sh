@@ -3,6 +3,7 @@
 # Script : build_animation.sh
 # Description : Exécute la simulation, convertit les fichiers DOT en PNG, crée une animation,
 #               et supprime les fichiers temporaires en conservant seulement le GIF et la vidéo.
+# Usage : ./build_animation.sh [fichier.txt]
 
 # Fonction pour afficher un message d'erreur et quitter
 function error_exit {
@@ -10,18 +11,19 @@ function error_exit {
     exit 1
 }
 
-# Vérifier si le programme visualizer existe
-if ! [ -x "./visualizer" ]; then
-  error_exit "Erreur : Le programme 'visualizer' n'existe pas ou n'est pas exécutable."
-fi
+# Fichier d'entrée : premier argument, example07.txt par défaut
+INPUT="${1:-../examples/example07.txt}"
 
 # Vérifier si le fichier d'entrée existe
-if ! [ -f "../examples/example.txt" ]; then
-  error_exit "Erreur : Le fichier d'entrée '../example/example00.txt' n'existe pas."
+if ! [ -f "$INPUT" ]; then
+  error_exit "Erreur : Le fichier d'entrée '$INPUT' n'existe pas."
 fi
 
+echo "0. Compilation de lem-in..."
+go build -o ./lem-in .. || error_exit "Erreur lors de la compilation de lem-in."
+
 echo "1. Exécution de la simulation..."
-./visualizer ../examples/example07.txt || error_exit "Erreur lors de l'exécution de la simulation."
+./lem-in visualize -clean "$INPUT" || error_exit "Erreur lors de l'exécution de la simulation."
 
 echo "2. Conversion des fichiers DOT en PNG..."
 for i in step_*.dot; do
@@ -45,7 +47,7 @@ if [ $? -ne 0 ]; then
 fi
 
 echo "5. Suppression des fichiers temporaires (.dot et .png)..."
-rm step_*.dot step_*.png
+rm step_*.dot step_*.png ./lem-in
 if [ $? -ne 0 ]; then
     error_exit "Erreur lors de la suppression des fichiers temporaires."
 fi
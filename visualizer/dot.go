@@ -0,0 +1,309 @@
+package visualizer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"lem-in/src"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Theme regroupe les couleurs Graphviz d'un rendu DOT.
+type Theme struct {
+	Background string // Couleur du fond, celle de Graphviz si vide
+	Font       string // Couleur du texte et du contour des salles, noir si vide
+	Start      string // Salles de départ
+	End        string // Salles d'arrivée
+	Occupied   string // Autres salles contenant au moins une fourmi
+	Room       string // Salles vides
+	Tunnel     string // Tunnels
+}
+
+// Themes contient les thèmes de couleurs disponibles, par nom.
+var Themes = map[string]Theme{
+	"default": {Start: "green", End: "red", Occupied: "lightblue", Room: "white", Tunnel: "gray"},
+	"dark":    {Background: "black", Font: "white", Start: "forestgreen", End: "firebrick", Occupied: "steelblue", Room: "gray20", Tunnel: "gray60"},
+	"mono":    {Start: "gray60", End: "gray35", Occupied: "gray85", Room: "white", Tunnel: "black"},
+}
+
+// ParseTheme renvoie le thème nommé "default", "dark" ou "mono".
+func ParseTheme(name string) (Theme, error) {
+	theme, ok := Themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (want default, dark or mono)", name)
+	}
+	return theme, nil
+}
+
+// fill renvoie la couleur de remplissage d'une salle dans ce thème.
+func (t Theme) fill(room *src.Room, occupied bool) string {
+	switch {
+	case room.IsStart:
+		return t.Start
+	case room.IsEnd:
+		return t.End
+	case occupied:
+		return t.Occupied
+	}
+	return t.Room
+}
+
+// DOTOptions règle la génération des fichiers DOT. Les champs vides ou nuls
+// prennent la valeur par défaut indiquée.
+type DOTOptions struct {
+	Dir      string // Dossier des fichiers ("." par défaut)
+	Template string // Nom des fichiers par tour, %d (ou %03d, ...) étant le numéro du tour ("step_%d.dot" par défaut)
+	Scale    int    // Facteur appliqué aux coordonnées des salles (100 par défaut)
+	Size     string // Taille de l'image en pouces, "largeur,hauteur" ("10,7.5" par défaut)
+	DPI      int    // Résolution de l'image (96 par défaut)
+	Theme    string // Nom du thème de couleurs ("default" par défaut)
+
+	// Combined, s'il n'est pas vide, est le nom d'un fichier DOT unique
+	// contenant un sous-graphe par tour, écrit à la place des fichiers par tour
+	Combined string
+
+	// Clean supprime d'abord de Dir les fichiers dont le nom correspond à
+	// Template, laissés par une exécution précédente
+	Clean bool
+}
+
+// templatePattern reconnaît un modèle de nom de fichier valide : un nom sans
+// dossier contenant un seul %d, éventuellement avec une largeur.
+var templatePattern = regexp.MustCompile(`^([^%/\\]*)%(0?[0-9]*)d([^%/\\]*)$`)
+
+// withDefaults renvoie les options complétées par les valeurs par défaut.
+func (o DOTOptions) withDefaults() DOTOptions {
+	if o.Dir == "" {
+		o.Dir = "."
+	}
+	if o.Template == "" {
+		o.Template = "step_%d.dot"
+	}
+	if o.Scale == 0 {
+		o.Scale = 100
+	}
+	if o.Size == "" {
+		o.Size = "10,7.5"
+	}
+	if o.DPI == 0 {
+		o.DPI = 96
+	}
+	if o.Theme == "" {
+		o.Theme = "default"
+	}
+	return o
+}
+
+// Check vérifie les options : modèle de nom de fichier, nom du fichier
+// unique, échelle, résolution et thème.
+func (o DOTOptions) Check() error {
+	o = o.withDefaults()
+	if !templatePattern.MatchString(o.Template) {
+		return fmt.Errorf("invalid file name template %q (want a file name with one %%d)", o.Template)
+	}
+	if o.Scale < 0 || o.DPI < 0 {
+		return fmt.Errorf("scale and dpi must be positive")
+	}
+	if o.Clean {
+		if match := templatePattern.FindStringSubmatch(o.Template); match[1] == "" && match[3] == "" {
+			return fmt.Errorf("file name template %q cannot be cleaned (want text before or after %%d)", o.Template)
+		}
+	}
+	if strings.ContainsAny(o.Combined, "/\\") {
+		return fmt.Errorf("invalid combined file name %q (want a file name inside the output directory)", o.Combined)
+	}
+	_, err := ParseTheme(o.Theme)
+	return err
+}
+
+// WriteDOT écrit au format DOT l'état actuel du graphe et des fourmis, chaque
+// salle étant placée selon ses coordonnées, avec les options par défaut.
+func WriteDOT(w io.Writer, lemInData *src.LemInData, antRooms map[int]string) error {
+	return DOTOptions{}.Write(w, lemInData, antRooms)
+}
+
+// Write écrit au format DOT l'état actuel du graphe et des fourmis.
+func (o DOTOptions) Write(w io.Writer, lemInData *src.LemInData, antRooms map[int]string) error {
+	o = o.withDefaults()
+	if err := o.Check(); err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	o.writeHeader(out)
+	o.writeState(out, lemInData, antRooms, "", 0, 0)
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// WriteCombined écrit dans un seul graphe DOT l'état après chaque tour : un
+// sous-graphe par tour, titré par son numéro, les tours étant rangés en grille
+// de gauche à droite puis de haut en bas.
+func (o DOTOptions) WriteCombined(w io.Writer, lemInData *src.LemInData, turns []src.Turn) error {
+	o = o.withDefaults()
+	if err := o.Check(); err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	o.writeHeader(out)
+
+	// Chaque tour occupe un bloc de la taille de la colonie, plus une marge
+	minX, minY, maxX, maxY := bounds(lemInData)
+	blockWidth := (maxX - minX + 2) * o.Scale
+	blockHeight := (maxY - minY + 3) * o.Scale
+	columns := int(math.Ceil(math.Sqrt(float64(len(turns)))))
+
+	for turn, antRooms := range Positions(lemInData, turns) {
+		offsetX := (turn%columns)*blockWidth - minX*o.Scale
+		offsetY := -(turn/columns)*blockHeight - minY*o.Scale
+		fmt.Fprintf(out, "    subgraph turn_%d {\n", turn+1)
+		fmt.Fprintf(out, "        \"turn_%d\" [shape=plaintext, style=\"\", label=\"Tour %d\", pos=\"%d,%d!\"];\n",
+			turn+1, turn+1, offsetX+(minX+maxX)*o.Scale/2, offsetY+(maxY+1)*o.Scale)
+		o.writeState(out, lemInData, antRooms, fmt.Sprintf("t%d_", turn+1), offsetX, offsetY)
+		fmt.Fprintln(out, "    }")
+	}
+
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// writeHeader écrit le début du graphe et ses attributs.
+func (o DOTOptions) writeHeader(out io.Writer) {
+	theme := Themes[o.Theme]
+	fmt.Fprintln(out, "graph G {")
+	fmt.Fprintln(out, "    layout=neato;")
+	fmt.Fprintf(out, "    size=\"%s!\";\n", o.Size) // Définit la taille en pouces, '!' force la taille exacte
+	fmt.Fprintln(out, "    ratio=fill;")            // Remplit l'espace défini
+	fmt.Fprintf(out, "    dpi=%d;\n", o.DPI)        // Résolution de l'image
+	if theme.Background != "" {
+		fmt.Fprintf(out, "    bgcolor=%s;\n", theme.Background)
+	}
+	if theme.Font != "" {
+		fmt.Fprintf(out, "    node [shape=circle, style=filled, color=%s, fontcolor=%s];\n", theme.Font, theme.Font)
+	} else {
+		fmt.Fprintln(out, "    node [shape=circle, style=filled];")
+	}
+	fmt.Fprintln(out, "    overlap=false;")
+	fmt.Fprintln(out, "    splines=true;")
+	fmt.Fprintln(out, "    sep=0.1;")
+	fmt.Fprintln(out, "    margin=0;")
+	fmt.Fprintf(out, "    edge [color=%s];\n", theme.Tunnel)
+}
+
+// writeState écrit les salles et les tunnels avec les fourmis antRooms. Les
+// nœuds sont nommés prefix suivi du nom de la salle et décalés de offsetX,
+// offsetY, pour que plusieurs tours puissent partager un graphe.
+func (o DOTOptions) writeState(out io.Writer, lemInData *src.LemInData, antRooms map[int]string, prefix string, offsetX, offsetY int) {
+	theme := Themes[o.Theme]
+	indent := "    "
+	if prefix != "" {
+		indent += "    "
+	}
+
+	// Map pour les positions des fourmis
+	antPositionsMap := antLabels(antRooms)
+
+	// Définir les nœuds avec les coordonnées, par ordre de nom pour que la
+	// sortie soit stable
+	for _, name := range roomNames(lemInData) {
+		room := lemInData.Rooms[name]
+		label := room.Name
+		ants, occupied := antPositionsMap[room.Name]
+		if occupied {
+			label = fmt.Sprintf("%s (%s)", room.Name, ants)
+		}
+		fmt.Fprintf(out, "%s%s [pos=\"%d,%d!\", label=%s, fillcolor=\"%s\"];\n", indent, dotQuote(prefix+room.Name),
+			offsetX+room.X*o.Scale, offsetY+room.Y*o.Scale, dotQuote(label), theme.fill(room, occupied))
+	}
+
+	// Définir les arêtes (tunnels)
+	for _, t := range tunnels(lemInData) {
+		if t.oneWay {
+			// Tunnel à sens unique : flèche vers la salle d'arrivée
+			fmt.Fprintf(out, "%s%s -- %s [dir=forward];\n", indent, dotQuote(prefix+t.from), dotQuote(prefix+t.to))
+		} else {
+			fmt.Fprintf(out, "%s%s -- %s;\n", indent, dotQuote(prefix+t.from), dotQuote(prefix+t.to))
+		}
+	}
+}
+
+// dotQuote renvoie s entre guillemets, échappés à l'intérieur.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// fileName renvoie le chemin du fichier du tour d'indice turn.
+func (o DOTOptions) fileName(turn int) string {
+	return filepath.Join(o.Dir, fmt.Sprintf(o.Template, turn))
+}
+
+// WriteFiles écrit les fichiers DOT de la simulation dans o.Dir : un par tour
+// nommé selon o.Template (le numéro 0 étant l'état après le premier tour),
+// ou le seul fichier o.Combined. Elle renvoie les chemins des fichiers écrits.
+func (o DOTOptions) WriteFiles(lemInData *src.LemInData, turns []src.Turn) ([]string, error) {
+	o = o.withDefaults()
+	if err := o.Check(); err != nil {
+		return nil, err
+	}
+	if o.Clean {
+		if _, err := o.RemoveStale(); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Combined != "" {
+		fileName := filepath.Join(o.Dir, o.Combined)
+		err := writeFile(fileName, func(w io.Writer) error { return o.WriteCombined(w, lemInData, turns) })
+		if err != nil {
+			return nil, err
+		}
+		return []string{fileName}, nil
+	}
+
+	var files []string
+	for turn, antRooms := range Positions(lemInData, turns) {
+		fileName := o.fileName(turn)
+		if err := writeFile(fileName, func(w io.Writer) error { return o.Write(w, lemInData, antRooms) }); err != nil {
+			return files, err
+		}
+		files = append(files, fileName)
+	}
+	return files, nil
+}
+
+// RemoveStale supprime de o.Dir les fichiers dont le nom correspond à
+// o.Template, par exemple les step_N.dot d'une exécution précédente qui
+// comptait plus de tours, et renvoie leurs chemins. Le modèle doit comporter
+// du texte avant ou après %d.
+func (o DOTOptions) RemoveStale() ([]string, error) {
+	o = o.withDefaults()
+	match := templatePattern.FindStringSubmatch(o.Template)
+	if match == nil {
+		return nil, fmt.Errorf("invalid file name template %q (want a file name with one %%d)", o.Template)
+	}
+	// Un modèle réduit à %d désignerait tous les fichiers au nom numérique
+	if match[1] == "" && match[3] == "" {
+		return nil, fmt.Errorf("file name template %q cannot be cleaned (want text before or after %%d)", o.Template)
+	}
+	stale := regexp.MustCompile("^" + regexp.QuoteMeta(match[1]) + "[0-9]+" + regexp.QuoteMeta(match[3]) + "$")
+
+	entries, err := os.ReadDir(o.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, entry := range entries {
+		if entry.IsDir() || !stale.MatchString(entry.Name()) {
+			continue
+		}
+		fileName := filepath.Join(o.Dir, entry.Name())
+		if err := os.Remove(fileName); err != nil {
+			return removed, err
+		}
+		removed = append(removed, fileName)
+	}
+	return removed, nil
+}
@@ -0,0 +1,85 @@
+package visualizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDOTOptionsCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  DOTOptions
+		valid bool
+	}{
+		{"defaults", DOTOptions{}, true},
+		{"padded template", DOTOptions{Template: "turn_%03d.dot"}, true},
+		{"template without %d", DOTOptions{Template: "turn.dot"}, false},
+		{"template with a directory", DOTOptions{Template: "out/%d.dot"}, false},
+		{"bare template", DOTOptions{Template: "%d"}, true},
+		{"bare template cleaned", DOTOptions{Template: "%d", Clean: true}, false},
+		{"negative scale", DOTOptions{Scale: -1}, false},
+		{"unknown theme", DOTOptions{Theme: "neon"}, false},
+		{"combined file in a directory", DOTOptions{Combined: "out/all.dot"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.opts.Check(); (err == nil) != test.valid {
+				t.Errorf("Check = %v; want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestDOTOptionsWriteFiles(t *testing.T) {
+	solution := solveColony(t)
+	dir := t.TempDir()
+
+	// Fichiers d'une exécution précédente plus longue, et un fichier étranger
+	for _, name := range []string{"turn_099.dot", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DOTOptions{Dir: dir, Template: "turn_%03d.dot", Theme: "dark", DPI: 150, Clean: true}
+	files, err := opts.WriteFiles(solution.Data, solution.Turns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(solution.Turns) || files[0] != filepath.Join(dir, "turn_000.dot") {
+		t.Errorf("files %v; want %d files from turn_000.dot", files, len(solution.Turns))
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"bgcolor=black", "dpi=150", "firebrick"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%s does not contain %s", files[0], want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "turn_099.dot")); !os.IsNotExist(err) {
+		t.Error("the stale turn_099.dot was not removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("notes.txt was removed: %v", err)
+	}
+
+	opts = DOTOptions{Dir: dir, Combined: "all.dot"}
+	files, err = opts.WriteFiles(solution.Data, solution.Turns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("files %v; want only all.dot", files)
+	}
+	content, err = os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subgraphs := strings.Count(string(content), "subgraph turn_"); subgraphs != len(solution.Turns) {
+		t.Errorf("%d subgraphs; want one per turn (%d)", subgraphs, len(solution.Turns))
+	}
+}
@@ -0,0 +1,89 @@
+package visualizer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+	"lem-in/src"
+	"text/template"
+)
+
+// replayPage est la page HTML de WriteHTML : le script qu'elle contient
+// dessine la colonie et rejoue les tours à partir des données insérées à la
+// place de {{.}}.
+//
+//go:embed replay.html
+var replayPage string
+
+var replayTemplate = template.Must(template.New("replay").Parse(replayPage))
+
+// replayData est ce que la page HTML reçoit, au format JSON.
+type replayData struct {
+	Rooms  []replayRoom       `json:"rooms"`
+	Links  []replayLink       `json:"links"`
+	Paths  [][]string         `json:"paths"`  // Chemins choisis par le solveur
+	Turns  [][]replayMove     `json:"turns"`  // Mouvements de chaque tour
+	Tracks [][]replayKeyframe `json:"tracks"` // Positions successives de chaque fourmi (L1 en premier)
+}
+
+type replayRoom struct {
+	Name  string `json:"name"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Start bool   `json:"start,omitempty"`
+	End   bool   `json:"end,omitempty"`
+}
+
+type replayLink struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	OneWay bool   `json:"oneWay,omitempty"`
+	Weight int    `json:"weight"`
+}
+
+type replayMove struct {
+	Ant int    `json:"ant"`
+	To  string `json:"to"`
+}
+
+type replayKeyframe struct {
+	Time float64 `json:"t"`
+	Room string  `json:"room"`
+}
+
+// WriteHTML écrit une page HTML autonome, utilisable hors ligne, qui rejoue
+// la simulation : salles à leurs coordonnées, tunnels, chemins choisis et
+// tours, avec lecture, pause, pas à pas, curseur de temps et mise en
+// évidence d'une fourmi.
+func WriteHTML(w io.Writer, lemInData *src.LemInData, paths [][]string, turns []src.Turn) error {
+	data := replayData{Paths: paths}
+	for _, name := range roomNames(lemInData) {
+		room := lemInData.Rooms[name]
+		data.Rooms = append(data.Rooms, replayRoom{Name: name, X: room.X, Y: room.Y, Start: room.IsStart, End: room.IsEnd})
+	}
+	for _, t := range tunnels(lemInData) {
+		data.Links = append(data.Links, replayLink{From: t.from, To: t.to, OneWay: t.oneWay, Weight: t.weight})
+	}
+	for _, turn := range turns {
+		moves := make([]replayMove, len(turn))
+		for i, move := range turn {
+			moves[i] = replayMove{Ant: move.Ant, To: move.To}
+		}
+		data.Turns = append(data.Turns, moves)
+	}
+	for _, track := range antTracks(lemInData, turns)[1:] {
+		keyframes := make([]replayKeyframe, len(track))
+		for i, k := range track {
+			keyframes[i] = replayKeyframe{Time: k.time, Room: k.room}
+		}
+		data.Tracks = append(data.Tracks, keyframes)
+	}
+
+	// json.Marshal échappe <, > et &, les noms de salles ne peuvent donc pas
+	// fermer la balise <script>
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return replayTemplate.Execute(w, string(encoded))
+}
@@ -0,0 +1,62 @@
+package visualizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	solution := solveColony(t)
+	var out bytes.Buffer
+	if err := WriteHTML(&out, solution.Data, solution.Paths, solution.Turns); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+
+	// La page fonctionne hors ligne : aucune ressource externe
+	for _, external := range []string{"<script src", "<link", `src="http`, `href="http`, "fetch("} {
+		if strings.Contains(page, external) {
+			t.Errorf("page contains %q", external)
+		}
+	}
+
+	// Les données insérées dans le script décrivent la colonie et les tours
+	const prefix = "const data = "
+	start := strings.Index(page, prefix)
+	if start < 0 {
+		t.Fatal("no data in the page")
+	}
+	encoded := page[start+len(prefix):]
+	encoded = encoded[:strings.Index(encoded, ";\n")]
+	var data replayData
+	if err := json.Unmarshal([]byte(encoded), &data); err != nil {
+		t.Fatalf("invalid data: %v", err)
+	}
+	if len(data.Rooms) != len(solution.Data.Rooms) || len(data.Links) != 4 {
+		t.Errorf("%d rooms and %d links; want %d and 4", len(data.Rooms), len(data.Links), len(solution.Data.Rooms))
+	}
+	if !reflect.DeepEqual(data.Paths, solution.Paths) {
+		t.Errorf("paths %v; want %v", data.Paths, solution.Paths)
+	}
+	if len(data.Turns) != len(solution.Turns) {
+		t.Fatalf("%d turns; want %d", len(data.Turns), len(solution.Turns))
+	}
+	for turn, moves := range solution.Turns {
+		for i, move := range moves {
+			if data.Turns[turn][i] != (replayMove{Ant: move.Ant, To: move.To}) {
+				t.Errorf("turn %d: move %+v; want %v", turn+1, data.Turns[turn][i], move)
+			}
+		}
+	}
+	if len(data.Tracks) != solution.Data.NumAnts {
+		t.Fatalf("%d ant tracks; want %d", len(data.Tracks), solution.Data.NumAnts)
+	}
+	for ant, track := range data.Tracks {
+		if last := track[len(track)-1]; last.Room != "e" {
+			t.Errorf("ant %d ends in %s; want e", ant+1, last.Room)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package visualizer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"lem-in/src"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// palette associe les noms de couleurs utilisés par roomColor et les
+// dessins à leur valeur RGBA.
+var palette = map[string]color.RGBA{
+	"white":      {255, 255, 255, 255},
+	"black":      {0, 0, 0, 255},
+	"gray":       {128, 128, 128, 255},
+	"lightblue":  {173, 216, 230, 255},
+	"green":      {0, 128, 0, 255},
+	"red":        {255, 0, 0, 255},
+	"darkorange": {255, 140, 0, 255},
+}
+
+// WritePNG dessine au format PNG les salles à leurs coordonnées, les tunnels
+// et les fourmis, avec la bibliothèque standard uniquement.
+func WritePNG(w io.Writer, lemInData *src.LemInData, antRooms map[int]string) error {
+	l := newLayout(lemInData, imageWidth, imageHeight)
+	return png.Encode(w, drawScene(lemInData, l, staticScene(lemInData, l, antRooms)))
+}
+
+// drawScene dessine une scène dans une nouvelle image.
+func drawScene(lemInData *src.LemInData, l layout, s scene) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{palette["white"]}, image.Point{}, draw.Src)
+
+	// Tunnels
+	for _, t := range tunnels(lemInData) {
+		from, to := l.position(lemInData.Rooms[t.from]), l.position(lemInData.Rooms[t.to])
+		drawLine(img, from, to, 2, palette["gray"])
+		if t.oneWay {
+			drawArrowHead(img, from, shorten(from, to, roomRadius), palette["gray"])
+		}
+		if t.weight > 1 {
+			drawText(img, strconv.Itoa(t.weight), point{X: (from.X + to.X) / 2, Y: (from.Y+to.Y)/2 - 14}, 2, palette["gray"])
+		}
+	}
+
+	// Salles, avec leur nom dessous et leur nombre de fourmis à côté
+	for _, name := range roomNames(lemInData) {
+		room := lemInData.Rooms[name]
+		at := l.position(room)
+		count := s.counts[name]
+		fillDisc(img, at, roomRadius, palette["black"])
+		fillDisc(img, at, roomRadius-1.5, palette[roomColor(room, count > 0)])
+		drawText(img, name, point{X: at.X, Y: at.Y + roomRadius + 4}, 2, palette["black"])
+		if showCount(room, count) {
+			text := strconv.Itoa(count)
+			drawText(img, text, point{X: at.X + roomRadius + 4 + float64(len(text))*4, Y: at.Y - roomRadius}, 2, palette["black"])
+		}
+	}
+
+	// Fourmis
+	for _, mark := range s.ants {
+		fillDisc(img, mark.at, antRadius+0.5, palette["black"])
+		fillDisc(img, mark.at, antRadius-0.5, palette["darkorange"])
+	}
+	return img
+}
+
+// fillDisc remplit le disque de centre c et de rayon r.
+func fillDisc(img *image.RGBA, c point, r float64, col color.RGBA) {
+	for y := int(math.Floor(c.Y - r)); y <= int(math.Ceil(c.Y+r)); y++ {
+		for x := int(math.Floor(c.X - r)); x <= int(math.Ceil(c.X+r)); x++ {
+			dx, dy := float64(x)+0.5-c.X, float64(y)+0.5-c.Y
+			if dx*dx+dy*dy <= r*r {
+				img.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+// drawLine trace le segment a-b avec une épaisseur de width pixels.
+func drawLine(img *image.RGBA, a, b point, width float64, col color.RGBA) {
+	steps := int(math.Ceil(math.Hypot(b.X-a.X, b.Y-a.Y) * 2))
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(max(steps, 1))
+		fillDisc(img, point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}, width/2, col)
+	}
+}
+
+// drawArrowHead dessine une pointe de flèche en tip, orientée de from vers tip.
+func drawArrowHead(img *image.RGBA, from, tip point, col color.RGBA) {
+	const length, half = 10.0, 5.0
+	dx, dy := tip.X-from.X, tip.Y-from.Y
+	norm := math.Hypot(dx, dy)
+	if norm == 0 {
+		return
+	}
+	dx, dy = dx/norm, dy/norm
+	base := point{X: tip.X - dx*length, Y: tip.Y - dy*length}
+	left := point{X: base.X - dy*half, Y: base.Y + dx*half}
+	right := point{X: base.X + dy*half, Y: base.Y - dx*half}
+
+	// Remplissage du triangle : un pixel est dedans s'il est du même côté
+	// des trois arêtes
+	side := func(p, a, b point) float64 { return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X) }
+	minX, maxX := math.Min(tip.X, math.Min(left.X, right.X)), math.Max(tip.X, math.Max(left.X, right.X))
+	minY, maxY := math.Min(tip.Y, math.Min(left.Y, right.Y)), math.Max(tip.Y, math.Max(left.Y, right.Y))
+	for y := int(minY); y <= int(maxY)+1; y++ {
+		for x := int(minX); x <= int(maxX)+1; x++ {
+			p := point{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+			s1, s2, s3 := side(p, tip, left), side(p, left, right), side(p, right, tip)
+			if (s1 >= 0 && s2 >= 0 && s3 >= 0) || (s1 <= 0 && s2 <= 0 && s3 <= 0) {
+				img.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+// drawText écrit text centré horizontalement sur at (at.Y est le haut du
+// texte) avec la police bitmap glyphs agrandie scale fois. Les minuscules
+// sont écrites en majuscules et les caractères inconnus laissés en blanc.
+func drawText(img *image.RGBA, text string, at point, scale int, col color.RGBA) {
+	runes := []rune(text)
+	advance := 4 * scale // 3 colonnes de glyphe et 1 d'espacement
+	x0 := int(at.X) - (len(runes)*advance-scale)/2
+	y0 := int(at.Y)
+	for i, r := range runes {
+		glyph, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			continue
+		}
+		for row, bits := range glyph {
+			for column := 0; column < 3; column++ {
+				if bits&(0b100>>column) == 0 {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						img.SetRGBA(x0+i*advance+column*scale+dx, y0+row*scale+dy, col)
+					}
+				}
+			}
+		}
+	}
+}
+
+// glyphs est une police bitmap de 3x5 pixels : chaque ligne est codée sur
+// 3 bits, le bit de poids fort étant la colonne de gauche.
+var glyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b001, 0b001, 0b001},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'A': {0b010, 0b101, 0b111, 0b101, 0b101},
+	'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+	'C': {0b011, 0b100, 0b100, 0b100, 0b011},
+	'D': {0b110, 0b101, 0b101, 0b101, 0b110},
+	'E': {0b111, 0b100, 0b110, 0b100, 0b111},
+	'F': {0b111, 0b100, 0b110, 0b100, 0b100},
+	'G': {0b011, 0b100, 0b101, 0b101, 0b011},
+	'H': {0b101, 0b101, 0b111, 0b101, 0b101},
+	'I': {0b111, 0b010, 0b010, 0b010, 0b111},
+	'J': {0b001, 0b001, 0b001, 0b101, 0b010},
+	'K': {0b101, 0b101, 0b110, 0b101, 0b101},
+	'L': {0b100, 0b100, 0b100, 0b100, 0b111},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	'N': {0b110, 0b101, 0b101, 0b101, 0b101},
+	'O': {0b010, 0b101, 0b101, 0b101, 0b010},
+	'P': {0b110, 0b101, 0b110, 0b100, 0b100},
+	'Q': {0b010, 0b101, 0b101, 0b110, 0b011},
+	'R': {0b110, 0b101, 0b110, 0b101, 0b101},
+	'S': {0b011, 0b100, 0b010, 0b001, 0b110},
+	'T': {0b111, 0b010, 0b010, 0b010, 0b010},
+	'U': {0b101, 0b101, 0b101, 0b101, 0b111},
+	'V': {0b101, 0b101, 0b101, 0b101, 0b010},
+	'W': {0b101, 0b101, 0b111, 0b111, 0b101},
+	'X': {0b101, 0b101, 0b010, 0b101, 0b101},
+	'Y': {0b101, 0b101, 0b010, 0b010, 0b010},
+	'Z': {0b111, 0b001, 0b010, 0b100, 0b111},
+	'_': {0b000, 0b000, 0b000, 0b000, 0b111},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+	'/': {0b001, 0b001, 0b010, 0b100, 0b100},
+}
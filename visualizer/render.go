@@ -0,0 +1,184 @@
+package visualizer
+
+import (
+	"lem-in/src"
+	"math"
+	"sort"
+)
+
+// Dimensions des images SVG et PNG, en pixels
+const (
+	imageWidth   = 960
+	imageHeight  = 720
+	imageMargin  = 48
+	roomRadius   = 16
+	antRadius    = 4
+	maxDrawnAnts = 8 // Au-delà, seul le nombre de fourmis de la salle est lisible
+)
+
+// point est une position dans l'image, en pixels.
+type point struct {
+	X, Y float64
+}
+
+// layout place les coordonnées des salles dans une image de taille donnée,
+// en conservant leurs proportions.
+type layout struct {
+	minX, minY int
+	scale      float64
+	offX, offY float64
+}
+
+// newLayout calcule l'échelle et le décalage qui font tenir toutes les
+// salles dans une image de width x height pixels.
+func newLayout(lemInData *src.LemInData, width, height int) layout {
+	minX, minY, maxX, maxY := bounds(lemInData)
+
+	// Une seule colonne ou ligne de salles : on évite la division par zéro
+	spanX := math.Max(float64(maxX-minX), 1)
+	spanY := math.Max(float64(maxY-minY), 1)
+	scale := math.Min(float64(width-2*imageMargin)/spanX, float64(height-2*imageMargin)/spanY)
+	return layout{
+		minX:  minX,
+		minY:  minY,
+		scale: scale,
+		offX:  (float64(width) - float64(maxX-minX)*scale) / 2,
+		offY:  (float64(height) - float64(maxY-minY)*scale) / 2,
+	}
+}
+
+// bounds renvoie les coordonnées extrêmes des salles.
+func bounds(lemInData *src.LemInData) (minX, minY, maxX, maxY int) {
+	first := true
+	for _, room := range lemInData.Rooms {
+		if first || room.X < minX {
+			minX = room.X
+		}
+		if first || room.Y < minY {
+			minY = room.Y
+		}
+		if first || room.X > maxX {
+			maxX = room.X
+		}
+		if first || room.Y > maxY {
+			maxY = room.Y
+		}
+		first = false
+	}
+	return minX, minY, maxX, maxY
+}
+
+// position renvoie le centre de la salle dans l'image.
+func (l layout) position(room *src.Room) point {
+	return point{
+		X: l.offX + float64(room.X-l.minX)*l.scale,
+		Y: l.offY + float64(room.Y-l.minY)*l.scale,
+	}
+}
+
+// antMark est une fourmi à dessiner.
+type antMark struct {
+	ant int
+	at  point
+}
+
+// scene est l'état à dessiner : la position de chaque fourmi et le nombre
+// de fourmis présentes dans chaque salle.
+type scene struct {
+	ants   []antMark
+	counts map[string]int
+}
+
+// staticScene place les fourmis dans leur salle, réparties en cercle autour
+// de son centre quand elles sont plusieurs.
+func staticScene(lemInData *src.LemInData, l layout, antRooms map[int]string) scene {
+	antsByRoom := make(map[string][]int)
+	for ant, room := range antRooms {
+		antsByRoom[room] = append(antsByRoom[room], ant)
+	}
+
+	s := scene{counts: make(map[string]int, len(antsByRoom))}
+	for _, name := range roomNames(lemInData) {
+		ants := antsByRoom[name]
+		if len(ants) == 0 {
+			continue
+		}
+		sort.Ints(ants)
+		s.counts[name] = len(ants)
+		center := l.position(lemInData.Rooms[name])
+		for i, ant := range ants {
+			if i == maxDrawnAnts {
+				break
+			}
+			s.ants = append(s.ants, antMark{ant: ant, at: antSlot(center, i, min(len(ants), maxDrawnAnts))})
+		}
+	}
+	return s
+}
+
+// antSlot renvoie la position de la i-ème des n fourmis dessinées dans une
+// salle de centre center.
+func antSlot(center point, i, n int) point {
+	if n == 1 {
+		return center
+	}
+	angle := 2*math.Pi*float64(i)/float64(n) - math.Pi/2
+	radius := float64(roomRadius) - antRadius - 2
+	return point{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)}
+}
+
+// tunnel est un tunnel à dessiner.
+type tunnel struct {
+	from, to string
+	oneWay   bool // Le tunnel ne se traverse que de from vers to
+	weight   int  // Nombre de tours nécessaires pour le traverser
+}
+
+// tunnels renvoie chaque tunnel une seule fois, dans un ordre stable.
+func tunnels(lemInData *src.LemInData) []tunnel {
+	var list []tunnel
+	added := make(map[[2]string]bool)
+	for _, name := range roomNames(lemInData) {
+		for _, linkName := range lemInData.Rooms[name].Links {
+			oneWay := lemInData.OneWay(name, linkName)
+			if !oneWay && (added[[2]string{name, linkName}] || added[[2]string{linkName, name}]) {
+				continue
+			}
+			added[[2]string{name, linkName}] = true
+			list = append(list, tunnel{from: name, to: linkName, oneWay: oneWay, weight: lemInData.LinkWeight(name, linkName)})
+		}
+	}
+	return list
+}
+
+// roomColor renvoie la couleur de remplissage d'une salle, sous forme d'un
+// nom de couleur compris par Graphviz et SVG.
+func roomColor(room *src.Room, occupied bool) string {
+	switch {
+	case room.IsStart:
+		return "green"
+	case room.IsEnd:
+		return "red"
+	case occupied:
+		return "lightblue"
+	}
+	return "white"
+}
+
+// showCount indique si le nombre de fourmis d'une salle doit être écrit :
+// toujours pour les salles de départ et d'arrivée, et dès que plusieurs
+// fourmis se trouvent dans une autre salle.
+func showCount(room *src.Room, count int) bool {
+	return count > 1 || (count > 0 && (room.IsStart || room.IsEnd))
+}
+
+// shorten renvoie l'extrémité du segment from-to reculée de by pixels, pour
+// qu'une flèche s'arrête au bord de la salle d'arrivée.
+func shorten(from, to point, by float64) point {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := math.Hypot(dx, dy)
+	if length <= by {
+		return to
+	}
+	return point{X: to.X - dx/length*by, Y: to.Y - dy/length*by}
+}
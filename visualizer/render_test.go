@@ -0,0 +1,83 @@
+package visualizer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	solution := solveColony(t)
+	for turn, antRooms := range Positions(solution.Data, solution.Turns) {
+		var out bytes.Buffer
+		if err := WriteSVG(&out, solution.Data, antRooms); err != nil {
+			t.Fatal(err)
+		}
+
+		// Le document doit être du XML bien formé, avec une infobulle par
+		// fourmi, une flèche pour le tunnel à sens unique et la longueur du
+		// long tunnel
+		var ants, arrows, lengths int
+		decoder := xml.NewDecoder(&out)
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("turn %d: invalid SVG: %v", turn+1, err)
+			}
+			switch element := token.(type) {
+			case xml.StartElement:
+				if element.Name.Local == "title" {
+					ants++
+				}
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "marker-end" {
+						arrows++
+					}
+				}
+			case xml.CharData:
+				if string(element) == "2" {
+					lengths++
+				}
+			}
+		}
+		if ants != solution.Data.NumAnts || arrows != 1 || lengths != 1 {
+			t.Errorf("turn %d: %d ants, %d arrows, %d tunnel lengths; want %d, 1, 1", turn+1, ants, arrows, lengths, solution.Data.NumAnts)
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	solution := solveColony(t)
+	for turn, antRooms := range Positions(solution.Data, solution.Turns) {
+		var out bytes.Buffer
+		if err := WritePNG(&out, solution.Data, antRooms); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&out)
+		if err != nil {
+			t.Fatalf("turn %d: invalid PNG: %v", turn+1, err)
+		}
+		if size := img.Bounds().Size(); size.X != imageWidth || size.Y != imageHeight {
+			t.Fatalf("turn %d: image of %v; want %dx%d", turn+1, size, imageWidth, imageHeight)
+		}
+
+		// Les fourmis sont dessinées en orange
+		orange := 0
+		for y := 0; y < imageHeight; y++ {
+			for x := 0; x < imageWidth; x++ {
+				r, g, b, _ := img.At(x, y).RGBA()
+				if r>>8 == 255 && g>>8 == 140 && b>>8 == 0 {
+					orange++
+				}
+			}
+		}
+		if orange == 0 {
+			t.Errorf("turn %d: no ant drawn", turn+1)
+		}
+	}
+}
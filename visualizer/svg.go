@@ -0,0 +1,60 @@
+package visualizer
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"lem-in/src"
+)
+
+// WriteSVG dessine au format SVG les salles à leurs coordonnées, les tunnels
+// et les fourmis, sans passer par Graphviz.
+func WriteSVG(w io.Writer, lemInData *src.LemInData, antRooms map[int]string) error {
+	l := newLayout(lemInData, imageWidth, imageHeight)
+	return writeSVG(w, lemInData, l, staticScene(lemInData, l, antRooms))
+}
+
+// writeSVG dessine une scène au format SVG.
+func writeSVG(w io.Writer, lemInData *src.LemInData, l layout, s scene) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\">\n", imageWidth, imageHeight, imageWidth, imageHeight)
+	fmt.Fprintln(out, "  <defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\"><path d=\"M0,0 L10,5 L0,10 z\" fill=\"gray\"/></marker></defs>")
+	fmt.Fprintln(out, "  <rect width=\"100%\" height=\"100%\" fill=\"white\"/>")
+
+	// Tunnels
+	for _, t := range tunnels(lemInData) {
+		from, to := l.position(lemInData.Rooms[t.from]), l.position(lemInData.Rooms[t.to])
+		if t.oneWay {
+			// Flèche vers la salle d'arrivée, arrêtée au bord du cercle
+			end := shorten(from, to, roomRadius)
+			fmt.Fprintf(out, "  <line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"gray\" stroke-width=\"2\" marker-end=\"url(#arrow)\"/>\n", from.X, from.Y, end.X, end.Y)
+		} else {
+			fmt.Fprintf(out, "  <line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"gray\" stroke-width=\"2\"/>\n", from.X, from.Y, to.X, to.Y)
+		}
+		if t.weight > 1 {
+			fmt.Fprintf(out, "  <text x=\"%.1f\" y=\"%.1f\" font-size=\"11\" fill=\"gray\" text-anchor=\"middle\">%d</text>\n", (from.X+to.X)/2, (from.Y+to.Y)/2-4, t.weight)
+		}
+	}
+
+	// Salles, avec leur nom dessous et leur nombre de fourmis à côté
+	for _, name := range roomNames(lemInData) {
+		room := lemInData.Rooms[name]
+		at := l.position(room)
+		count := s.counts[name]
+		fmt.Fprintf(out, "  <circle cx=\"%.1f\" cy=\"%.1f\" r=\"%d\" fill=\"%s\" stroke=\"black\"/>\n", at.X, at.Y, roomRadius, roomColor(room, count > 0))
+		fmt.Fprintf(out, "  <text x=\"%.1f\" y=\"%.1f\" font-size=\"12\" text-anchor=\"middle\">%s</text>\n", at.X, at.Y+roomRadius+13, html.EscapeString(name))
+		if showCount(room, count) {
+			fmt.Fprintf(out, "  <text x=\"%.1f\" y=\"%.1f\" font-size=\"11\" font-weight=\"bold\">%d</text>\n", at.X+roomRadius+3, at.Y-roomRadius+4, count)
+		}
+	}
+
+	// Fourmis, avec leur nom en infobulle
+	for _, mark := range s.ants {
+		fmt.Fprintf(out, "  <circle cx=\"%.1f\" cy=\"%.1f\" r=\"%d\" fill=\"darkorange\" stroke=\"black\" stroke-width=\"0.5\"><title>L%d</title></circle>\n", mark.at.X, mark.at.Y, antRadius, mark.ant)
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return out.Flush()
+}
@@ -0,0 +1,375 @@
+package visualizer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"lem-in/src"
+	"math"
+	"strings"
+	"time"
+)
+
+// Couleurs ANSI du rendu dans le terminal
+const (
+	ansiReset  = "\x1b[0m"
+	ansiDim    = "\x1b[2m"
+	ansiGreen  = "\x1b[1;32m"
+	ansiRed    = "\x1b[1;31m"
+	ansiCyan   = "\x1b[1;36m"
+	ansiYellow = "\x1b[1;33m"
+	ansiClear  = "\x1b[H\x1b[2J"
+)
+
+// statusLines est le nombre de lignes réservées sous le plan à l'état du tour
+// et à l'aide.
+const statusLines = 3
+
+// cell est un caractère du plan et sa couleur.
+type cell struct {
+	r     rune
+	color string
+}
+
+// Terminal affiche la simulation dans un terminal ANSI : les salles sont
+// placées selon leurs coordonnées sur une grille de Width x Height
+// caractères, les tunnels sont tracés avec des caractères de dessin de
+// boîtes et les fourmis avancent tour par tour.
+type Terminal struct {
+	Width, Height int
+
+	// LineMode indique que le terminal n'a pas pu être passé en mode
+	// caractère : les commandes sont validées par Entrée, et Entrée seule
+	// passe au tour suivant.
+	LineMode bool
+
+	lemInData *src.LemInData
+	turns     []src.Turn
+	tracks    [][]keyframe
+}
+
+// NewTerminal prépare l'affichage des tours turns dans un terminal de
+// width x height caractères.
+func NewTerminal(lemInData *src.LemInData, turns []src.Turn, width, height int) *Terminal {
+	return &Terminal{
+		Width:     width,
+		Height:    height,
+		lemInData: lemInData,
+		turns:     turns,
+		tracks:    antTracks(lemInData, turns),
+	}
+}
+
+// place renvoie la case de la grille où est dessinée chaque salle. Une case
+// étant environ deux fois plus haute que large, l'échelle verticale est
+// divisée par deux pour conserver les proportions.
+func (t *Terminal) place(rows int) map[string][2]int {
+	// Marge à droite pour le nom des salles
+	labelWidth := 0
+	for name := range t.lemInData.Rooms {
+		labelWidth = max(labelWidth, len(name)+4)
+	}
+	labelWidth = min(labelWidth, t.Width/3)
+
+	minX, minY, maxX, maxY := bounds(t.lemInData)
+	spanX := math.Max(float64(maxX-minX), 1)
+	spanY := math.Max(float64(maxY-minY), 1)
+	scale := math.Min(float64(t.Width-1-labelWidth)/spanX, 2*float64(rows-1)/spanY)
+
+	cells := make(map[string][2]int, len(t.lemInData.Rooms))
+	for name, room := range t.lemInData.Rooms {
+		x := int(math.Round(float64(room.X-minX) * scale))
+		y := int(math.Round(float64(room.Y-minY) * scale / 2))
+		cells[name] = [2]int{min(max(x, 0), t.Width-1), min(max(y, 0), rows-1)}
+	}
+	return cells
+}
+
+// Frame renvoie l'écran complet, codes ANSI compris, à la fin du tour turn
+// (0 pour l'état initial).
+func (t *Terminal) Frame(turn int) string {
+	rows := max(t.Height-statusLines, 1)
+	grid := make([][]cell, rows)
+	for y := range grid {
+		grid[y] = make([]cell, t.Width)
+		for x := range grid[y] {
+			grid[y][x] = cell{r: ' '}
+		}
+	}
+	set := func(x, y int, r rune, color string) {
+		if y >= 0 && y < rows && x >= 0 && x < t.Width {
+			grid[y][x] = cell{r: r, color: color}
+		}
+	}
+	cells := t.place(rows)
+
+	// Tunnels, avec une flèche dans le sens des tunnels à sens unique
+	for _, tun := range tunnels(t.lemInData) {
+		from := cells[tun.from]
+		steps := lineCells(from, cells[tun.to])
+		previous := from
+		for _, c := range steps {
+			set(c[0], c[1], merge(grid[c[1]][c[0]].r, stepRune(previous, c)), ansiDim)
+			previous = c
+		}
+		if tun.oneWay && len(steps) > 0 {
+			// Au milieu du tunnel, la flèche n'est pas recouverte par le nom des salles
+			middle := steps[len(steps)/2]
+			set(middle[0], middle[1], arrowRune(from, cells[tun.to]), ansiDim)
+		}
+	}
+
+	// Fourmis : dans une salle, ou dans un tunnel pendant sa traversée
+	counts := make(map[string]int)
+	var moving [][2]int
+	for ant := 1; ant < len(t.tracks); ant++ {
+		from, to, progress := trackPosition(t.tracks[ant], float64(turn))
+		if to == "" {
+			counts[from]++
+			continue
+		}
+		a, b := cells[from], cells[to]
+		moving = append(moving, [2]int{
+			a[0] + int(math.Round(float64(b[0]-a[0])*progress)),
+			a[1] + int(math.Round(float64(b[1]-a[1])*progress)),
+		})
+	}
+	for _, c := range moving {
+		set(c[0], c[1], '•', ansiYellow)
+	}
+
+	// Salles, suivies de leur nom et de leur nombre de fourmis
+	colors := make(map[string]string)
+	for _, name := range roomNames(t.lemInData) {
+		switch roomColor(t.lemInData.Rooms[name], counts[name] > 0) {
+		case "green":
+			colors[name] = ansiGreen
+		case "red":
+			colors[name] = ansiRed
+		case "lightblue":
+			colors[name] = ansiCyan
+		}
+		c := cells[name]
+		set(c[0], c[1], '●', colors[name])
+	}
+	for _, name := range roomNames(t.lemInData) {
+		c := cells[name]
+		label := name
+		if counts[name] > 0 {
+			label = fmt.Sprintf("%s(%d)", name, counts[name])
+		}
+		for i, r := range []rune(label) {
+			// Le nom recouvre les tunnels mais s'arrête avant une autre salle
+			x := c[0] + 1 + i
+			if x >= t.Width || grid[c[1]][x].r == '●' {
+				break
+			}
+			set(x, c[1], r, colors[name])
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(ansiClear)
+	for _, row := range grid {
+		color := ""
+		for _, c := range row {
+			if c.color != color {
+				out.WriteString(ansiReset + c.color)
+				color = c.color
+			}
+			out.WriteRune(c.r)
+		}
+		out.WriteString(ansiReset + "\n")
+	}
+
+	// État du tour et aide
+	moves := ""
+	if turn > 0 {
+		names := make([]string, len(t.turns[turn-1]))
+		for i, move := range t.turns[turn-1] {
+			names[i] = fmt.Sprintf("L%d-%s", move.Ant, move.To)
+		}
+		moves = strings.Join(names, " ")
+	}
+	fmt.Fprintf(&out, "Tour %d/%d  %s\n", turn, len(t.turns), truncate(moves, t.Width-12))
+	help := "←/h précédent  →/l suivant  espace lecture  g début  G fin  q quitter"
+	if t.LineMode {
+		help = "Entrée suivant  p précédent  g début  G fin  q quitter (puis Entrée)"
+	}
+	out.WriteString(ansiDim + truncate(help, t.Width) + ansiReset + "\n")
+	return out.String()
+}
+
+// Run affiche la simulation sur out et la fait avancer selon les touches
+// lues sur in, jusqu'à q ou la fin de in. Espace lance ou arrête la lecture
+// automatique, à un tour par seconde.
+func (t *Terminal) Run(in io.Reader, out io.Writer) error {
+	keys := make(chan string)
+	go readKeys(bufio.NewReader(in), keys)
+
+	turn, playing := 0, false
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if _, err := io.WriteString(out, t.Frame(turn)); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+			if !playing {
+				continue
+			}
+			if turn < len(t.turns) {
+				turn++
+			}
+			playing = turn < len(t.turns)
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch key {
+			case "q":
+				return nil
+			case "right", "l", "n":
+				turn, playing = min(turn+1, len(t.turns)), false
+			case "left", "h", "p", "b":
+				turn, playing = max(turn-1, 0), false
+			case "home", "g":
+				turn, playing = 0, false
+			case "end", "G":
+				turn, playing = len(t.turns), false
+			case " ":
+				playing = !playing && turn < len(t.turns)
+			case "enter":
+				if t.LineMode {
+					turn = min(turn+1, len(t.turns))
+				}
+			}
+		}
+	}
+}
+
+// readKeys envoie sur keys chaque touche lue, les flèches et Début/Fin
+// étant décodées ("left", "right", "home", "end"). En mode ligne, seule une
+// ligne vide produit "enter". keys est fermé à la fin de la lecture.
+func readKeys(in *bufio.Reader, keys chan<- string) {
+	defer close(keys)
+	lineStart := true
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return
+		}
+		switch b {
+		case '\n', '\r':
+			if lineStart {
+				keys <- "enter"
+			}
+			lineStart = true
+			continue
+		case 0x1b:
+			// Séquence ESC [ X des flèches et de Début/Fin
+			if next, err := in.ReadByte(); err != nil || next != '[' {
+				continue
+			}
+			code, err := in.ReadByte()
+			if err != nil {
+				return
+			}
+			if name, ok := map[byte]string{'C': "right", 'D': "left", 'H': "home", 'F': "end"}[code]; ok {
+				keys <- name
+			}
+		default:
+			keys <- string(b)
+		}
+		lineStart = false
+	}
+}
+
+// lineCells renvoie les cases traversées par un tunnel entre les cases de
+// deux salles, sans ces deux cases.
+func lineCells(from, to [2]int) [][2]int {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	steps := max(abs(dx), abs(dy))
+	var cells [][2]int
+	for i := 1; i < steps; i++ {
+		cells = append(cells, [2]int{
+			from[0] + int(math.Round(float64(dx*i)/float64(steps))),
+			from[1] + int(math.Round(float64(dy*i)/float64(steps))),
+		})
+	}
+	return cells
+}
+
+// stepRune renvoie le caractère de dessin de boîtes d'une case d'un tunnel
+// selon la direction depuis la case précédente.
+func stepRune(previous, c [2]int) rune {
+	dx, dy := c[0]-previous[0], c[1]-previous[1]
+	switch {
+	case dy == 0:
+		return '─'
+	case dx == 0:
+		return '│'
+	case (dx > 0) == (dy > 0):
+		return '╲'
+	}
+	return '╱'
+}
+
+// merge renvoie le caractère d'une case où se croisent deux tunnels.
+func merge(existing, r rune) rune {
+	if (existing == '─' && r == '│') || (existing == '│' && r == '─') || existing == '┼' {
+		return '┼'
+	}
+	if (existing == '╲' && r == '╱') || (existing == '╱' && r == '╲') || existing == '╳' {
+		return '╳'
+	}
+	return r
+}
+
+// arrowRune renvoie la flèche indiquant le sens d'un tunnel à sens unique
+// allant de la case from à la case to. Une case étant environ deux fois plus
+// haute que large, l'écart vertical compte double.
+func arrowRune(from, to [2]int) rune {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	sx, sy := sign(dx), sign(dy)
+	if 2*abs(dy)*2 < abs(dx) {
+		sy = 0
+	} else if abs(dx)*2 < 2*abs(dy) {
+		sx = 0
+	}
+	arrows := map[[2]int]rune{
+		{1, 0}: '→', {-1, 0}: '←', {0, 1}: '↓', {0, -1}: '↑',
+		{1, 1}: '↘', {-1, -1}: '↖', {1, -1}: '↗', {-1, 1}: '↙',
+	}
+	return arrows[[2]int{sx, sy}]
+}
+
+// truncate coupe s à width caractères.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width < 1 {
+		return ""
+	}
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return s
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package visualizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// ansiCode reconnaît les codes ANSI de couleur et d'effacement.
+var ansiCode = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+func TestTerminalFrame(t *testing.T) {
+	solution := solveColony(t)
+	const width, height = 60, 16
+	terminal := NewTerminal(solution.Data, solution.Turns, width, height)
+
+	inTunnel := false
+	for turn := 0; turn <= len(solution.Turns); turn++ {
+		frame := ansiCode.ReplaceAllString(terminal.Frame(turn), "")
+		lines := strings.Split(strings.TrimSuffix(frame, "\n"), "\n")
+		if len(lines) > height {
+			t.Errorf("turn %d: %d lines; want at most %d", turn, len(lines), height)
+		}
+		for _, line := range lines {
+			if utf8.RuneCountInString(line) > width {
+				t.Errorf("turn %d: line %q is wider than %d", turn, line, width)
+			}
+		}
+		if !strings.Contains(frame, fmt.Sprintf("Tour %d/%d", turn, len(solution.Turns))) {
+			t.Errorf("turn %d: no turn status in\n%s", turn, frame)
+		}
+		// La fourmi qui traverse le long tunnel a-e y est dessinée
+		inTunnel = inTunnel || strings.Contains(frame, "•")
+	}
+
+	first := ansiCode.ReplaceAllString(terminal.Frame(0), "")
+	last := ansiCode.ReplaceAllString(terminal.Frame(len(solution.Turns)), "")
+	if !strings.Contains(first, "s(3)") || !strings.Contains(last, "e(3)") {
+		t.Errorf("ants not shown in the start room first and the end room last:\n%s\n%s", first, last)
+	}
+	if !inTunnel {
+		t.Error("no ant drawn in the long tunnel")
+	}
+}
@@ -0,0 +1,97 @@
+package visualizer
+
+import (
+	"bytes"
+	"fmt"
+	"lem-in/lemin"
+	"lem-in/src"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testColony comporte un long tunnel (a-e) et un tunnel à sens unique (s>b),
+// pour que les rendus passent par tous les cas.
+const testColony = `3
+##start
+s 0 0
+a 2 0
+b 2 2
+##end
+e 4 1
+s-a
+s>b
+a-e 2
+b-e
+`
+
+// solveColony résout testColony, puis vérifie les mouvements avec src.Verify
+// et les compare à la borne inférieure.
+func solveColony(t *testing.T) *lemin.Solution {
+	t.Helper()
+	solution, err := lemin.Solve(strings.NewReader(testColony), lemin.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var moves bytes.Buffer
+	if err := solution.WriteMoves(&moves); err != nil {
+		t.Fatal(err)
+	}
+	turns, err := src.Verify(solution.Data, &moves)
+	if err != nil {
+		t.Fatalf("Verify: %v\n%s", err, moves.String())
+	}
+	if turns < solution.Bound.Turns {
+		t.Fatalf("%d turns, below the lower bound of %d", turns, solution.Bound.Turns)
+	}
+	return solution
+}
+
+func TestPositions(t *testing.T) {
+	solution := solveColony(t)
+	positions := Positions(solution.Data, solution.Turns)
+	if len(positions) != len(solution.Turns) {
+		t.Fatalf("%d positions for %d turns", len(positions), len(solution.Turns))
+	}
+	for turn, moves := range solution.Turns {
+		for _, move := range moves {
+			if room := positions[turn][move.Ant]; room != move.To {
+				t.Errorf("turn %d: ant %d in %s; want %s", turn+1, move.Ant, room, move.To)
+			}
+		}
+	}
+	for ant, room := range positions[len(positions)-1] {
+		if room != "e" {
+			t.Errorf("ant %d ends in %s; want e", ant, room)
+		}
+	}
+}
+
+func TestWriteStepFiles(t *testing.T) {
+	solution := solveColony(t)
+	dir := t.TempDir()
+	files, err := WriteStepFiles(dir, "dot", solution.Data, solution.Turns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(solution.Turns) {
+		t.Fatalf("%d files for %d turns", len(files), len(solution.Turns))
+	}
+	for turn, file := range files {
+		if want := filepath.Join(dir, fmt.Sprintf("step_%d.dot", turn)); file != want {
+			t.Errorf("file %q; want %q", file, want)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(content), "graph G {") {
+			t.Errorf("%s does not start a DOT graph", file)
+		}
+	}
+
+	if _, err := WriteStepFiles(dir, "jpeg", solution.Data, solution.Turns); err == nil {
+		t.Error("WriteStepFiles accepted the jpeg format")
+	}
+}